@@ -0,0 +1,257 @@
+package tea
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	// defaultTabWidth is the distance between tab stops on most terminals.
+	defaultTabWidth = 8
+
+	escape = '\x1b'
+	bell   = '\a'
+)
+
+// ansiSeqLen returns the length in bytes of the escape sequence at the start
+// of s, or 0 if s doesn't begin with one. Incomplete sequences are measured up
+// to the point where they stop looking like a sequence.
+func ansiSeqLen(s []byte) int {
+	if len(s) == 0 || s[0] != escape {
+		return 0
+	}
+	if len(s) == 1 {
+		return 1
+	}
+
+	switch s[1] {
+	case '[': // CSI: parameters and intermediates followed by a final byte
+		i := 2
+		for i < len(s) && s[i] >= 0x20 && s[i] <= 0x3f {
+			i++
+		}
+		if i < len(s) && s[i] >= 0x40 && s[i] <= 0x7e {
+			i++
+		}
+		return i
+
+	case ']', 'P', 'X', '^', '_': // OSC, DCS and friends: terminated by BEL or ST
+		for i := 2; i < len(s); i++ {
+			if s[i] == bell {
+				return i + 1
+			}
+			if s[i] == escape && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return len(s)
+
+	case 'O': // SS3: a single character follows
+		if len(s) > 2 {
+			return 3
+		}
+		return 2
+	}
+
+	// Everything else is an escape, any number of intermediate bytes, and
+	// a final byte.
+	i := 1
+	for i < len(s) && s[i] >= 0x20 && s[i] <= 0x2f {
+		i++
+	}
+	if i < len(s) {
+		i++
+	}
+	return i
+}
+
+// ExpandTabs replaces tabs in s with spaces, out to the next tab stop, as a
+// terminal would. Tab stops are placed every tabWidth cells, usually 8, and
+// each line starts at column zero. Escape sequences don't advance the column
+// and wide runes advance it by their full width. If tabWidth isn't positive s
+// is returned untouched.
+//
+// Views are expanded like this before they're drawn, see WithTabWidth, so
+// there's only a need to call ExpandTabs on text that's measured or laid out
+// before then, such as source code placed in a column.
+func ExpandTabs(s string, tabWidth int) string {
+	if tabWidth <= 0 || !strings.ContainsRune(s, '\t') {
+		return s
+	}
+
+	var (
+		b   strings.Builder
+		col int
+		raw = []byte(s)
+	)
+	b.Grow(len(s))
+
+	for i := 0; i < len(s); {
+		if n := ansiSeqLen(raw[i:]); n > 0 {
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch r {
+		case '\t':
+			n := tabWidth - col%tabWidth
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+		case '\n', '\r':
+			b.WriteRune(r)
+			col = 0
+		default:
+			b.WriteString(s[i : i+size])
+			col += runewidth.RuneWidth(r)
+		}
+		i += size
+	}
+
+	return b.String()
+}
+
+// printableWidth returns the number of cells s takes up on screen. Escape
+// sequences don't take up any room and wide runes take up their full width.
+func printableWidth(s []byte) int {
+	var w int
+	for i := 0; i < len(s); {
+		if n := ansiSeqLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRune(s[i:])
+		w += runewidth.RuneWidth(r)
+		i += size
+	}
+	return w
+}
+
+// StringWidth returns the number of cells s takes up on screen. Escape
+// sequences, such as colors, don't take up any room and wide runes, such as
+// those in Chinese, Japanese and Korean text, take up their full width.
+func StringWidth(s string) int {
+	return printableWidth([]byte(s))
+}
+
+// MaxWidth returns the width of the widest line of s, as StringWidth measures
+// it. It's for finding out how much room a multi-line view, or a piece of one,
+// takes up across.
+func MaxWidth(s string) int {
+	var width int
+	for rest, last := []byte(s), false; !last; {
+		var line []byte
+		line, rest, last = nextLine(rest)
+		if w := printableWidth(line); w > width {
+			width = w
+		}
+	}
+	return width
+}
+
+// Height returns the number of lines in s, which is one more than the number
+// of line breaks. Like SplitLines, it counts a trailing line break as ending
+// the line before an empty one.
+func Height(s string) int {
+	return strings.Count(s, "\n") + 1
+}
+
+// StripANSI returns s with all escape sequences removed, leaving just the
+// text. It's handy for logging styled text or comparing it with plain text.
+// Unlike Sanitize, control characters other than escapes are kept. The width
+// StringWidth reports is the width of what StripANSI leaves.
+func StripANSI(s string) string {
+	raw := []byte(s)
+	if bytes.IndexByte(raw, escape) < 0 {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(raw); {
+		if n := ansiSeqLen(raw[i:]); n > 0 {
+			i += n
+			continue
+		}
+		b.WriteByte(raw[i])
+		i++
+	}
+	return b.String()
+}
+
+// Sanitize makes untrusted text, such as the contents of a file or lines from
+// a log, safe to include in a view. Escape sequences are removed, as are
+// control characters other than newlines and tabs, so the text can't change
+// colors, move the cursor or otherwise take over the terminal. Invalid UTF-8
+// is replaced with the Unicode replacement character.
+//
+// Sanitize the text before styling it, not after, or the styling will be
+// removed too.
+func Sanitize(s string) string {
+	var (
+		b   strings.Builder
+		raw = []byte(s)
+	)
+	b.Grow(len(s))
+
+	for i := 0; i < len(s); {
+		if n := ansiSeqLen(raw[i:]); n > 0 {
+			i += n
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == '\n' || r == '\t' || !unicode.IsControl(r) {
+			b.WriteRune(r)
+		}
+		i += size
+	}
+
+	return b.String()
+}
+
+// findUnsafeControl returns the index of the first control character or
+// escape sequence in s which could do more than style text, or -1 if there
+// isn't one. Line endings, tabs and SGR sequences, which set colors and text
+// attributes, are considered safe.
+func findUnsafeControl(s []byte) int {
+	for i := 0; i < len(s); {
+		if n := ansiSeqLen(s[i:]); n > 0 {
+			if !isSGR(s[i : i+n]) {
+				return i
+			}
+			i += n
+			continue
+		}
+
+		r, size := utf8.DecodeRune(s[i:])
+		if r == '\r' && i+1 < len(s) && s[i+1] == '\n' {
+			i += 2
+			continue
+		}
+		if r != '\n' && r != '\t' && unicode.IsControl(r) {
+			return i
+		}
+		i += size
+	}
+	return -1
+}
+
+// isSGR reports whether seq is a complete SGR sequence, which sets colors and
+// text attributes and nothing else.
+func isSGR(seq []byte) bool {
+	if len(seq) < 3 || seq[0] != escape || seq[1] != '[' || seq[len(seq)-1] != 'm' {
+		return false
+	}
+	for _, c := range seq[2 : len(seq)-1] {
+		if (c < '0' || c > '9') && c != ';' && c != ':' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package tea
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandTabs(t *testing.T) {
+	sp := func(n int) string { return strings.Repeat(" ", n) }
+	tests := []struct {
+		name     string
+		in       string
+		tabWidth int
+		want     string
+	}{
+		{"no tabs", "abc", 8, "abc"},
+		{"leading", "\tx", 8, sp(8) + "x"},
+		{"mid-stop", "ab\tx", 8, "ab" + sp(6) + "x"},
+		{"on a stop", "abcdefgh\tx", 8, "abcdefgh" + sp(8) + "x"},
+		{"consecutive", "a\t\tx", 4, "a" + sp(3) + sp(4) + "x"},
+		{"narrow stops", "abc\tx", 2, "abc" + sp(1) + "x"},
+
+		// Wide runes take up two columns.
+		{"after wide rune", "世\tx", 8, "世" + sp(6) + "x"},
+		{"wide runes up to a stop", "世界世界\tx", 8, "世界世界" + sp(8) + "x"},
+		{"wide rune across a stop", "abcdefg世\tx", 8, "abcdefg世" + sp(7) + "x"},
+		{"before wide rune", "a\t世", 8, "a" + sp(7) + "世"},
+		{"between wide runes", "世\t界\t", 4, "世" + sp(2) + "界" + sp(2)},
+		{"after combining mark", "e\u0301\tx", 8, "e\u0301" + sp(7) + "x"},
+
+		// Each line starts at column zero again.
+		{"end of line", "ab\t\ncd\t", 8, "ab" + sp(6) + "\ncd" + sp(6)},
+		{"end of wide line", "世界\t\n\t", 8, "世界" + sp(4) + "\n" + sp(8)},
+		{"carriage return", "abc\r\tx", 8, "abc\r" + sp(8) + "x"},
+		{"only a tab", "\t", 8, sp(8)},
+
+		// Escape sequences don't take up any room.
+		{"after color", "\x1b[31mab\x1b[0m\tx", 8, "\x1b[31mab\x1b[0m" + sp(6) + "x"},
+		{"after title", "\x1b]0;title\a\tx", 8, "\x1b]0;title\a" + sp(8) + "x"},
+
+		{"no tab width", "a\tb", 0, "a\tb"},
+		{"negative tab width", "a\tb", -1, "a\tb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExpandTabs(tt.in, tt.tabWidth); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
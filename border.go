@@ -0,0 +1,136 @@
+package tea
+
+import "strings"
+
+// BorderStyle is the set of characters Border draws a border with. Each
+// should take up a single cell.
+type BorderStyle struct {
+	Top         string
+	Bottom      string
+	Left        string
+	Right       string
+	TopLeft     string
+	TopRight    string
+	BottomLeft  string
+	BottomRight string
+
+	// Junctions, where lines inside a box meet its edges and each other. Only
+	// TableString uses them. Any left empty are drawn with the matching edge.
+	MiddleLeft   string
+	MiddleRight  string
+	MiddleTop    string
+	MiddleBottom string
+	Middle       string
+}
+
+// Border styles for use with Border.
+var (
+	NormalBorder = BorderStyle{
+		Top: "─", Bottom: "─", Left: "│", Right: "│",
+		TopLeft: "┌", TopRight: "┐", BottomLeft: "└", BottomRight: "┘",
+		MiddleLeft: "├", MiddleRight: "┤", MiddleTop: "┬", MiddleBottom: "┴", Middle: "┼",
+	}
+	RoundedBorder = BorderStyle{
+		Top: "─", Bottom: "─", Left: "│", Right: "│",
+		TopLeft: "╭", TopRight: "╮", BottomLeft: "╰", BottomRight: "╯",
+		MiddleLeft: "├", MiddleRight: "┤", MiddleTop: "┬", MiddleBottom: "┴", Middle: "┼",
+	}
+	ThickBorder = BorderStyle{
+		Top: "━", Bottom: "━", Left: "┃", Right: "┃",
+		TopLeft: "┏", TopRight: "┓", BottomLeft: "┗", BottomRight: "┛",
+		MiddleLeft: "┣", MiddleRight: "┫", MiddleTop: "┳", MiddleBottom: "┻", Middle: "╋",
+	}
+	DoubleBorder = BorderStyle{
+		Top: "═", Bottom: "═", Left: "║", Right: "║",
+		TopLeft: "╔", TopRight: "╗", BottomLeft: "╚", BottomRight: "╝",
+		MiddleLeft: "╠", MiddleRight: "╣", MiddleTop: "╦", MiddleBottom: "╩", Middle: "╬",
+	}
+
+	// HiddenBorder takes up the same room as the others but draws nothing,
+	// which is handy for keeping things lined up with bordered blocks.
+	HiddenBorder = BorderStyle{
+		Top: " ", Bottom: " ", Left: " ", Right: " ",
+		TopLeft: " ", TopRight: " ", BottomLeft: " ", BottomRight: " ",
+		MiddleLeft: " ", MiddleRight: " ", MiddleTop: " ", MiddleBottom: " ", Middle: " ",
+	}
+)
+
+// Border draws a border around s in the given style. The border is sized to
+// fit the widest line of s, and shorter lines are padded out to meet it.
+// Escape sequences don't count towards the width, so styled text is boxed up
+// properly.
+func Border(style BorderStyle, s string) string {
+	lines := SplitLines(s)
+	width := MaxWidth(s)
+
+	var b strings.Builder
+	b.WriteString(style.TopLeft)
+	b.WriteString(strings.Repeat(style.Top, width))
+	b.WriteString(style.TopRight)
+	for _, l := range lines {
+		b.WriteByte('\n')
+		b.WriteString(style.Left)
+		b.WriteString(Pad(l, width, AlignLeft))
+		b.WriteString(style.Right)
+	}
+	b.WriteByte('\n')
+	b.WriteString(style.BottomLeft)
+	b.WriteString(strings.Repeat(style.Bottom, width))
+	b.WriteString(style.BottomRight)
+	return b.String()
+}
+
+// RuleOption is used to set options when drawing a rule with HorizontalRule.
+// A BorderStyle is a RuleOption too, setting the style the rule's drawn in.
+type RuleOption interface {
+	applyRule(*ruleOptions)
+}
+
+// ruleOptions is how a rule is drawn.
+type ruleOptions struct {
+	line  string
+	color string
+}
+
+// ruleOptionFunc is a RuleOption that's a function.
+type ruleOptionFunc func(*ruleOptions)
+
+func (f ruleOptionFunc) applyRule(o *ruleOptions) {
+	f(o)
+}
+
+// applyRule draws a rule with the top edge of the style, if it has one.
+func (s BorderStyle) applyRule(o *ruleOptions) {
+	if s.Top != "" {
+		o.line = s.Top
+	}
+}
+
+// RuleColor colors a rule. The color is given as for ColorFg.
+func RuleColor(color string) RuleOption {
+	return ruleOptionFunc(func(o *ruleOptions) {
+		o.color = color
+	})
+}
+
+// HorizontalRule returns a line width cells long, for dividing one part of a
+// view from another. It's drawn with the top edge of the border style given,
+// or NormalBorder's if there isn't one, so it matches any borders nearby, and
+// colored with RuleColor:
+//
+//   tea.HorizontalRule(m.width, tea.RoundedBorder, tea.RuleColor("240"))
+func HorizontalRule(width int, opts ...RuleOption) string {
+	if width <= 0 {
+		return ""
+	}
+	o := ruleOptions{line: NormalBorder.Top}
+	for _, opt := range opts {
+		opt.applyRule(&o)
+	}
+
+	rule := strings.Repeat(o.line, width)
+	if o.color != "" {
+		rule = ColorFg(o.color, rule)
+	}
+	return rule
+}
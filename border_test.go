@@ -0,0 +1,52 @@
+package tea
+
+import (
+	"testing"
+
+	te "github.com/muesli/termenv"
+)
+
+func TestHorizontalRule(t *testing.T) {
+	defer useColorProfile(te.ANSI)()
+
+	tests := []struct {
+		width int
+		opts  []RuleOption
+		want  string
+	}{
+		{3, nil, "───"},
+		{3, []RuleOption{ThickBorder}, "━━━"},
+		{3, []RuleOption{BorderStyle{}}, "───"},
+		{3, []RuleOption{RuleColor("1")}, "\x1b[31m───\x1b[0m"},
+		{2, []RuleOption{DoubleBorder, RuleColor("1")}, "\x1b[31m══\x1b[0m"},
+		{2, []RuleOption{ThickBorder, DoubleBorder}, "══"},
+		{2, []RuleOption{RuleColor("1"), RuleColor("")}, "──"},
+		{0, []RuleOption{RuleColor("1")}, ""},
+		{-1, nil, ""},
+	}
+	for _, tt := range tests {
+		if got := HorizontalRule(tt.width, tt.opts...); got != tt.want {
+			t.Errorf("HorizontalRule(%d, %v): got %q, want %q", tt.width, tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestHorizontalRuleWidth(t *testing.T) {
+	defer useColorProfile(te.ANSI)()
+
+	// However it's drawn, a rule is as wide as it's asked to be.
+	for _, style := range []BorderStyle{NormalBorder, RoundedBorder, ThickBorder, DoubleBorder, HiddenBorder} {
+		for _, width := range []int{1, 7, 80} {
+			if got := StringWidth(HorizontalRule(width, style, RuleColor("1"))); got != width {
+				t.Errorf("%q rule %d wide: got %d cells", style.Top, width, got)
+			}
+		}
+	}
+}
+
+func TestHorizontalRuleNoColor(t *testing.T) {
+	defer useColorProfile(te.Ascii)()
+	if got, want := HorizontalRule(3, RuleColor("1")), "───"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,111 @@
+package tea
+
+import "strings"
+
+// BoxOption is used to set options when drawing a box with BoxString.
+type BoxOption func(*boxOptions)
+
+// boxOptions is how a box is drawn.
+type boxOptions struct {
+	border      BorderStyle
+	borderColor string
+	background  string
+	margin      [4]int // top, right, bottom, left
+	padding     [4]int
+}
+
+// BoxBorder sets the style of the box's border. The default is NormalBorder.
+func BoxBorder(style BorderStyle) BoxOption {
+	return func(o *boxOptions) {
+		o.border = style
+	}
+}
+
+// BoxBorderColor colors the box's border. The color is given as for ColorFg.
+func BoxBorderColor(color string) BoxOption {
+	return func(o *boxOptions) {
+		o.borderColor = color
+	}
+}
+
+// BoxBackground colors the background inside the box, padding included. The
+// color is given as for ColorFg. Styled text in the box, such as text colored
+// with ColorFg, resets the background where its styling ends, so the
+// background shows around it but not after it on the same line.
+func BoxBackground(color string) BoxOption {
+	return func(o *boxOptions) {
+		o.background = color
+	}
+}
+
+// BoxMargin sets the blank space around the outside of the box, as for
+// Margin.
+func BoxMargin(top, right, bottom, left int) BoxOption {
+	return func(o *boxOptions) {
+		o.margin = [4]int{top, right, bottom, left}
+	}
+}
+
+// BoxPadding sets the blank space between the border and the text inside the
+// box, as for Padding.
+func BoxPadding(top, right, bottom, left int) BoxOption {
+	return func(o *boxOptions) {
+		o.padding = [4]int{top, right, bottom, left}
+	}
+}
+
+// BoxString draws s in a box: padded with Padding, surrounded with Border and
+// spaced out with Margin, all in one go. For example, a dialog:
+//
+//   tea.BoxString("Save changes?",
+//       tea.BoxBorder(tea.RoundedBorder),
+//       tea.BoxBorderColor("63"),
+//       tea.BoxPadding(1, 2, 1, 2),
+//   )
+//
+// With no options, it's the same as Border with NormalBorder. Colors are
+// converted for the program's output as for ColorFg, and left out if the
+// output has none, which also makes it the same.
+func BoxString(s string, opts ...BoxOption) string {
+	o := boxOptions{border: NormalBorder}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	p := o.padding
+	s = Padding(p[0], p[1], p[2], p[3], s)
+	if o.background != "" {
+		lines := SplitLines(s)
+		for i, l := range lines {
+			lines[i] = ColorBg(o.background, l)
+		}
+		s = strings.Join(lines, "\n")
+	}
+
+	s = Border(o.border, s)
+	if o.borderColor != "" {
+		s = colorBorder(s, o.border, o.borderColor)
+	}
+
+	m := o.margin
+	if m != [4]int{} {
+		s = Margin(m[0], m[1], m[2], m[3], s)
+	}
+	return s
+}
+
+// colorBorder colors the border of a box drawn with Border, leaving what's
+// inside it alone.
+func colorBorder(box string, style BorderStyle, color string) string {
+	lines := SplitLines(box)
+	last := len(lines) - 1
+	for i, l := range lines {
+		if i == 0 || i == last {
+			lines[i] = ColorFg(color, l)
+			continue
+		}
+		inner := strings.TrimSuffix(strings.TrimPrefix(l, style.Left), style.Right)
+		lines[i] = ColorFg(color, style.Left) + inner + ColorFg(color, style.Right)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,57 @@
+package tea
+
+import (
+	"testing"
+
+	te "github.com/muesli/termenv"
+)
+
+func TestBoxString(t *testing.T) {
+	defer useColorProfile(te.ANSI)()
+	tests := []struct {
+		name string
+		s    string
+		opts []BoxOption
+		want string
+	}{
+		{"plain", "hi", nil, "┌──┐\n│hi│\n└──┘"},
+		{"padded", "hi", []BoxOption{BoxBorder(RoundedBorder), BoxPadding(0, 1, 0, 1)}, "╭────╮\n│ hi │\n╰────╯"},
+		{"margin", "hi", []BoxOption{BoxMargin(1, 0, 0, 2)}, "      \n  ┌──┐\n  │hi│\n  └──┘"},
+
+		// Only the border's colored, not what's inside it.
+		{
+			"border color", "a\nbcd", []BoxOption{BoxBorderColor("1"), BoxPadding(1, 0, 0, 0)},
+			"\x1b[31m┌───┐\x1b[0m\n" +
+				"\x1b[31m│\x1b[0m   \x1b[31m│\x1b[0m\n" +
+				"\x1b[31m│\x1b[0ma  \x1b[31m│\x1b[0m\n" +
+				"\x1b[31m│\x1b[0mbcd\x1b[31m│\x1b[0m\n" +
+				"\x1b[31m└───┘\x1b[0m",
+		},
+
+		// The background fills the inside, padding and all, a line at a
+		// time.
+		{
+			"background", "a\nbcd", []BoxOption{BoxBackground("4"), BoxPadding(0, 1, 0, 1)},
+			"┌─────┐\n│\x1b[44m a   \x1b[0m│\n│\x1b[44m bcd \x1b[0m│\n└─────┘",
+		},
+		{
+			"background around color", "x" + ColorFg("2", "y") + "z", []BoxOption{BoxBackground("4")},
+			"┌───┐\n│\x1b[44mx\x1b[32my\x1b[0mz\x1b[0m│\n└───┘",
+		},
+	}
+	for _, tt := range tests {
+		if got := BoxString(tt.s, tt.opts...); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBoxStringNoColor(t *testing.T) {
+	defer useColorProfile(te.Ascii)()
+
+	// Without colors, a colored box is just a box.
+	got := BoxString("hi", BoxBorderColor("1"), BoxBackground("4"))
+	if want := BoxString("hi"); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
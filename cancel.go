@@ -0,0 +1,73 @@
+package tea
+
+import "sync"
+
+// CancelToken cancels commands made with WithCancel. Create one with
+// NewCancelToken. A token can only be cancelled once; to cancel later
+// commands, make a new token for them.
+//
+//   case KeyMsg:
+//       switch msg.String() {
+//       case "enter":
+//           m.search = NewCancelToken()
+//           return m, WithCancel(m.search, search(m.query))
+//       case "esc":
+//           return m, CancelCmd(m.search)
+//       }
+type CancelToken struct {
+	once sync.Once
+	done chan struct{}
+}
+
+// NewCancelToken returns a new CancelToken.
+func NewCancelToken() *CancelToken {
+	return &CancelToken{done: make(chan struct{})}
+}
+
+// Done returns a channel that's closed when the token is cancelled. Long
+// running commands can watch it to give up early.
+func (t *CancelToken) Done() <-chan struct{} {
+	return t.done
+}
+
+// Cancel cancels the token. It's safe to call more than once and from any
+// goroutine.
+func (t *CancelToken) Cancel() {
+	t.once.Do(func() {
+		close(t.done)
+	})
+}
+
+// WithCancel runs a command which can be cancelled with the given token. If
+// the token is cancelled by the time the command finishes, its message is
+// dropped rather than sent to Update. The command itself still runs to
+// completion unless it watches the token's Done channel.
+//
+// Commands whose work is done once the program runs them, such as Tick and
+// CmdWithContext, count as finished once that work is, and commands made with
+// Batch are cancelled together.
+func WithCancel(token *CancelToken, cmd Cmd) Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return mapCmd(cmd, func(msg Msg) Msg {
+		select {
+		case <-token.Done():
+			return cancelledMsg{}
+		default:
+			return msg
+		}
+	})
+}
+
+// CancelCmd is a command that cancels the given token.
+func CancelCmd(token *CancelToken) Cmd {
+	return func() Msg {
+		token.Cancel()
+		return cancelledMsg{}
+	}
+}
+
+// cancelledMsg is an internal message that takes the place of the result of
+// a cancelled command. It's never sent to Update.
+type cancelledMsg struct{}
@@ -0,0 +1,79 @@
+package tea
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWithCancel(t *testing.T) {
+	second := TickedMsg(time.Date(2020, 1, 1, 0, 0, 1, 0, time.UTC))
+	tests := []struct {
+		name  string
+		cmd   Cmd
+		timer int
+		want  Msg
+	}{
+		{"Tick", Tick(time.Second, tickFn), 1, second},
+		{"Every", Every(time.Second, tickFn), 1, second},
+		{"FrameTick", FrameTick(time.Second, tickFn), 1, second},
+		{"Batch", Batch(Tick(time.Second, tickFn), Tick(time.Hour, tickFn)), 2, second},
+		{"Request", Request("id", Tick(time.Second, tickFn)), 1, second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" cancelled before finishing", func(t *testing.T) {
+			p := startTestProgram(t)
+			defer p.stop()
+
+			token := NewCancelToken()
+			p.run(WithCancel(token, tt.cmd))
+			p.advance(tt.timer, 0)
+			p.run(CancelCmd(token))
+			<-token.Done()
+			p.clock.Advance(time.Second)
+			p.expectNone(50 * time.Millisecond)
+		})
+
+		t.Run(tt.name+" cancelled after finishing", func(t *testing.T) {
+			p := startTestProgram(t)
+			defer p.stop()
+
+			token := NewCancelToken()
+			p.run(WithCancel(token, tt.cmd))
+			p.advance(tt.timer, time.Second)
+			p.expect(tt.want)
+			p.run(CancelCmd(token))
+			p.expectNone(50 * time.Millisecond)
+		})
+	}
+}
+
+func TestWithCancelCmdWithContext(t *testing.T) {
+	for _, cancel := range []bool{false, true} {
+		p := startTestProgram(t)
+
+		var (
+			token   = NewCancelToken()
+			started = make(chan struct{})
+			finish  = make(chan struct{})
+		)
+		p.run(WithCancel(token, CmdWithContext(func(context.Context) Msg {
+			close(started)
+			<-finish
+			return "done"
+		})))
+		<-started
+		if cancel {
+			token.Cancel()
+		}
+		close(finish)
+
+		if cancel {
+			p.expectNone(50 * time.Millisecond)
+		} else {
+			p.expect("done")
+		}
+		p.stop()
+	}
+}
@@ -0,0 +1,42 @@
+package tea
+
+import (
+	"errors"
+	"io"
+)
+
+// errReadCancelled is returned by reads from the input once they've been
+// cancelled.
+var errReadCancelled = errors.New("read cancelled")
+
+// inputReader is the program's input, wrapped so that a read from it can be
+// cancelled when the terminal's handed over to something else, where that's
+// possible.
+type inputReader interface {
+	io.Reader
+
+	// cancel makes the read underway, if there is one, and any that follow
+	// return errReadCancelled until resume is called. It reports whether
+	// reads can be cancelled at all.
+	cancel() bool
+
+	// wait waits for the read underway, if there is one, to return.
+	wait()
+
+	// resume lets reads go ahead again after they've been cancelled.
+	resume()
+
+	// close releases anything held to make reads cancellable. It's only to
+	// be called once reading's done with.
+	close()
+}
+
+// plainReader is an inputReader for input that reads can't be cancelled on.
+type plainReader struct {
+	io.Reader
+}
+
+func (plainReader) cancel() bool { return false }
+func (plainReader) wait()        {}
+func (plainReader) resume()      {}
+func (plainReader) close()       {}
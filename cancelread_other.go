@@ -0,0 +1,12 @@
+// +build darwin windows
+
+package tea
+
+import "io"
+
+// newInputReader wraps the input for reading. Reads can't be cancelled here:
+// poll doesn't work with terminals on macOS, and Windows has nothing like it
+// for console input.
+func newInputReader(r io.Reader) inputReader {
+	return plainReader{r}
+}
@@ -0,0 +1,112 @@
+// +build dragonfly freebsd linux netbsd openbsd solaris
+
+package tea
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+// newInputReader wraps the input so that reads from it can be cancelled, if
+// it's a file. Reads wait for the file to be readable with poll, along with
+// a pipe that's written to when they're cancelled, so a cancelled read never
+// takes anything from the file.
+func newInputReader(r io.Reader) inputReader {
+	f, ok := r.(*os.File)
+	if !ok {
+		return plainReader{r}
+	}
+	wakeR, wakeW, err := os.Pipe()
+	if err != nil {
+		return plainReader{r}
+	}
+	return &pollReader{
+		f:      f,
+		fd:     int32(f.Fd()),
+		wakeR:  wakeR,
+		wakeW:  wakeW,
+		wakeFd: int32(wakeR.Fd()),
+	}
+}
+
+// pollReader is an inputReader for a file.
+type pollReader struct {
+	f      *os.File
+	fd     int32
+	wakeR  *os.File
+	wakeW  *os.File
+	wakeFd int32
+
+	// held while reading, so that wait can wait for a read to return
+	reading sync.Mutex
+
+	mtx       sync.Mutex
+	cancelled bool
+}
+
+// Read reads from the file once it's readable, unless the read is cancelled
+// first.
+func (r *pollReader) Read(b []byte) (int, error) {
+	r.reading.Lock()
+	defer r.reading.Unlock()
+
+	r.mtx.Lock()
+	cancelled := r.cancelled
+	r.mtx.Unlock()
+	if cancelled {
+		return 0, errReadCancelled
+	}
+
+	fds := []unix.PollFd{
+		{Fd: r.fd, Events: unix.POLLIN},
+		{Fd: r.wakeFd, Events: unix.POLLIN},
+	}
+	for {
+		_, err := unix.Poll(fds, -1)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		break
+	}
+	if fds[1].Revents != 0 {
+		return 0, errReadCancelled
+	}
+	// Errors and hang-ups are left to the read to report.
+	return r.f.Read(b)
+}
+
+func (r *pollReader) cancel() bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if !r.cancelled {
+		r.cancelled = true
+		_, _ = r.wakeW.Write([]byte{0})
+	}
+	return true
+}
+
+func (r *pollReader) wait() {
+	r.reading.Lock()
+	r.reading.Unlock()
+}
+
+func (r *pollReader) resume() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.cancelled {
+		// Take back the byte cancel wrote, so that the next poll waits.
+		_, _ = r.wakeR.Read(make([]byte, 1))
+		r.cancelled = false
+	}
+}
+
+func (r *pollReader) close() {
+	r.wakeR.Close()
+	r.wakeW.Close()
+}
@@ -0,0 +1,230 @@
+package tea
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is where a program gets the time from. Everything time-based the
+// program does goes through it: Tick, Every and Sleep, timers started with
+// StartTimer, WatchConfig's wait for a file to settle, the renderer's frame
+// rate, polling for resizes and timeouts such as the one for bracketed paste.
+//
+// Programs use the system clock unless they're given another with WithClock.
+// That's mostly useful for tests, which can use a TestClock to move time
+// along by hand rather than waiting for it to pass.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is a single event from a Clock, like time.Timer.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// Ticker is a repeating event from a Clock, like time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Sleep is a command that waits for the given duration and then sends msg.
+// If the program shuts down in the meantime, it gives up straight away and
+// nothing is sent.
+func Sleep(d time.Duration, msg Msg) Cmd {
+	return func() Msg {
+		return clockCmdMsg(func(ctx context.Context, c Clock) Msg {
+			if _, ok := sleep(ctx, c, d); !ok {
+				return nil
+			}
+			return msg
+		})
+	}
+}
+
+// clockCmdMsg is an internal message carrying a command that needs the
+// program's context and clock. Tick, Every and friends send one.
+type clockCmdMsg func(context.Context, Clock) Msg
+
+// sleep waits on c for the given duration, unless ctx is done first. It
+// returns the time the wait ended and whether it ran its full course.
+func sleep(ctx context.Context, c Clock, d time.Duration) (time.Time, bool) {
+	t := c.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case now := <-t.C():
+		return now, true
+	case <-ctx.Done():
+		return time.Time{}, false
+	}
+}
+
+// systemClock is the Clock programs use by default.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTimer struct{ t *time.Timer }
+
+func (t systemTimer) C() <-chan time.Time { return t.t.C }
+func (t systemTimer) Stop() bool          { return t.t.Stop() }
+
+type systemTicker struct{ t *time.Ticker }
+
+func (t systemTicker) C() <-chan time.Time { return t.t.C }
+func (t systemTicker) Stop()               { t.t.Stop() }
+
+// TestClock is a Clock for tests. Time stands still until it's moved along
+// with Advance, at which point any timers and tickers that are due fire, in
+// order. This makes time-based behavior quick and deterministic to test:
+//
+//   clock := tea.NewTestClock(time.Now())
+//   p := tea.NewProgram(init, update, view, tea.WithClock(clock))
+//   go p.Start()
+//
+//   clock.BlockUntil(1)          // wait for the program to start its timer
+//   clock.Advance(time.Second)   // and make it fire
+//
+// Keep in mind that the renderer's frame rate comes from the clock too, so
+// frames are only drawn as time is advanced.
+type TestClock struct {
+	mtx     sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiting []*testTimer
+}
+
+// NewTestClock returns a TestClock set to the given time.
+func NewTestClock(now time.Time) *TestClock {
+	c := &TestClock{now: now}
+	c.cond = sync.NewCond(&c.mtx)
+	return c
+}
+
+// Now returns the clock's current time.
+func (c *TestClock) Now() time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.now
+}
+
+// NewTimer returns a Timer that fires once the clock has been advanced by d.
+func (c *TestClock) NewTimer(d time.Duration) Timer {
+	return c.add(d, 0)
+}
+
+// NewTicker returns a Ticker that fires each time the clock has been advanced
+// by another d. Like time.NewTicker, it panics if d isn't positive.
+func (c *TestClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for TestClock.NewTicker")
+	}
+	return testTicker{c.add(d, d)}
+}
+
+// Advance moves the clock forward by d, firing any timers and tickers that
+// come due along the way, in the order they come due. As with the system
+// clock, a ticker that isn't keeping up skips ticks.
+func (c *TestClock) Advance(d time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	end := c.now.Add(d)
+	for len(c.waiting) > 0 && !c.waiting[0].at.After(end) {
+		t := c.waiting[0]
+		c.now = t.at
+		select {
+		case t.c <- c.now:
+		default:
+		}
+
+		if t.period > 0 {
+			t.at = t.at.Add(t.period)
+		} else {
+			c.waiting = c.waiting[1:]
+		}
+		c.sortWaiting()
+	}
+	c.now = end
+}
+
+// BlockUntil waits until at least n timers and tickers are waiting to fire.
+// It's for making sure a program has got as far as starting a timer before
+// advancing the clock past it.
+func (c *TestClock) BlockUntil(n int) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for len(c.waiting) < n {
+		c.cond.Wait()
+	}
+}
+
+// add sets up a timer, or a ticker if period is positive, to fire after d.
+func (c *TestClock) add(d, period time.Duration) *testTimer {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	t := &testTimer{clock: c, at: c.now.Add(d), period: period, c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- c.now
+		return t
+	}
+	c.waiting = append(c.waiting, t)
+	c.sortWaiting()
+	c.cond.Broadcast()
+	return t
+}
+
+// sortWaiting puts the waiting timers in the order they're due. Timers due at
+// the same time stay in the order they were added. The mutex must be held
+// when calling this.
+func (c *TestClock) sortWaiting() {
+	sort.SliceStable(c.waiting, func(i, j int) bool {
+		return c.waiting[i].at.Before(c.waiting[j].at)
+	})
+}
+
+// testTimer is a timer or ticker from a TestClock.
+type testTimer struct {
+	clock  *TestClock
+	at     time.Time
+	period time.Duration
+	c      chan time.Time
+}
+
+func (t *testTimer) C() <-chan time.Time { return t.c }
+
+// Stop stops the timer. It returns false if the timer had already fired or
+// been stopped.
+func (t *testTimer) Stop() bool {
+	c := t.clock
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for i, w := range c.waiting {
+		if w == t {
+			c.waiting = append(c.waiting[:i], c.waiting[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// testTicker is a ticker from a TestClock.
+type testTicker struct{ *testTimer }
+
+func (t testTicker) Stop() { t.testTimer.Stop() }
@@ -0,0 +1,188 @@
+package tea
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	te "github.com/muesli/termenv"
+)
+
+var (
+	stdoutProfile     te.Profile
+	stdoutProfileOnce sync.Once
+
+	// the programs that are running, in the order they started, whose
+	// outputs' colors ColorFg and the like use
+	colorProgramsMtx sync.Mutex
+	colorPrograms    []*Program
+)
+
+// ColorFg colors the text of s. The color can be given as a hex code, such as
+// "#ff8700", or as an ANSI color number, such as "208" or "1". It's converted
+// to the nearest color the terminal supports, and if it supports none, or the
+// color isn't valid, s is returned untouched.
+//
+// ColorFg is for coloring a word or two without reaching for a styling
+// library. The colors the terminal supports are those of the output of the
+// program started last, as Program.ColorProfile gave them when it started.
+// Once that program exits they're those of the program started before it, if
+// it's still running, and so on, or of stdout when no program is running.
+func ColorFg(color, s string) string {
+	return te.String(s).Foreground(colorProfile().Color(color)).String()
+}
+
+// ColorBg colors the background of s. The color is given as for ColorFg.
+func ColorBg(color, s string) string {
+	return te.String(s).Background(colorProfile().Color(color)).String()
+}
+
+// Gradient colors the text of s with a gradient, shifting from one color to
+// the other a character at a time. Colors are blended in a way that looks
+// even to the eye, and converted to the nearest color the terminal supports,
+// so terminals without true color get a coarser gradient and those without
+// color get s untouched. The colors the terminal supports are worked out as
+// for ColorFg.
+//
+// Escape sequences in s are kept but don't take part in the gradient, and
+// line breaks carry on where the line before left off. As with ColorFg, the
+// color is reset at the end of s, and at the end of each line.
+func Gradient(from, to te.Color, s string) string {
+	var (
+		start = te.ConvertToRGB(from)
+		end   = te.ConvertToRGB(to)
+		raw   = []byte(s)
+		total int
+	)
+	for i := 0; i < len(raw); {
+		if l := ansiSeqLen(raw[i:]); l > 0 {
+			i += l
+			continue
+		}
+		r, size := utf8.DecodeRune(raw[i:])
+		if r != '\n' {
+			total++
+		}
+		i += size
+	}
+
+	var (
+		b       strings.Builder
+		n       int
+		last    string
+		colored bool
+		profile = colorProfile()
+	)
+	b.Grow(len(s) * 4)
+	for i := 0; i < len(raw); {
+		if l := ansiSeqLen(raw[i:]); l > 0 {
+			b.Write(raw[i : i+l])
+			last = "" // the sequence may have changed the color
+			i += l
+			continue
+		}
+
+		r, size := utf8.DecodeRune(raw[i:])
+		if r == '\n' {
+			if colored {
+				b.WriteString(resetSeq)
+				colored, last = false, ""
+			}
+			b.WriteByte('\n')
+			i++
+			continue
+		}
+
+		var t float64
+		if total > 1 {
+			t = float64(n) / float64(total-1)
+		}
+		c := profile.Color(start.BlendLab(end, t).Clamped().Hex())
+		if c != nil {
+			if seq := c.Sequence(false); seq != "" && seq != last {
+				b.WriteString(te.CSI + seq + "m")
+				colored, last = true, seq
+			}
+		}
+		b.Write(raw[i : i+size])
+		n++
+		i += size
+	}
+	if colored {
+		b.WriteString(resetSeq)
+	}
+	return b.String()
+}
+
+// colorProfile returns the colors ColorFg and the like convert colors to.
+func colorProfile() te.Profile {
+	colorProgramsMtx.Lock()
+	defer colorProgramsMtx.Unlock()
+	if n := len(colorPrograms); n > 0 {
+		return colorPrograms[n-1].profile
+	}
+	return stdoutColorProfile()
+}
+
+// addColorProgram has ColorFg and the like convert colors to those of p's
+// output, until p is removed with removeColorProgram.
+func addColorProgram(p *Program) {
+	colorProgramsMtx.Lock()
+	defer colorProgramsMtx.Unlock()
+	colorPrograms = append(colorPrograms, p)
+}
+
+// removeColorProgram stops ColorFg and the like converting colors to those of
+// p's output, going back to those of the program started before it.
+func removeColorProgram(p *Program) {
+	colorProgramsMtx.Lock()
+	defer colorProgramsMtx.Unlock()
+	for i, q := range colorPrograms {
+		if q == p {
+			colorPrograms = append(colorPrograms[:i], colorPrograms[i+1:]...)
+			return
+		}
+	}
+}
+
+// stdoutColorProfile returns the colors stdout supports.
+func stdoutColorProfile() te.Profile {
+	stdoutProfileOnce.Do(func() {
+		stdoutProfile = outputColorProfile(os.Stdout)
+	})
+	return stdoutProfile
+}
+
+// Bold makes s bold. Like the other text attribute helpers, Italic, Underline
+// and so on, it's for styling a word or two without reaching for a styling
+// library. Text attributes are reset at the end of s, so they don't bleed
+// into what follows, though that also ends any styling s is nested in.
+func Bold(s string) string {
+	return te.String(s).Bold().String()
+}
+
+// Faint makes s faint, or dim. See Bold.
+func Faint(s string) string {
+	return te.String(s).Faint().String()
+}
+
+// Italic makes s italic. See Bold.
+func Italic(s string) string {
+	return te.String(s).Italic().String()
+}
+
+// Underline underlines s. See Bold.
+func Underline(s string) string {
+	return te.String(s).Underline().String()
+}
+
+// Strikethrough strikes s through. See Bold.
+func Strikethrough(s string) string {
+	return te.String(s).CrossOut().String()
+}
+
+// Blink makes s blink, on terminals that support it. See Bold.
+func Blink(s string) string {
+	return te.String(s).Blink().String()
+}
@@ -0,0 +1,127 @@
+package tea
+
+import (
+	"io/ioutil"
+	"testing"
+
+	te "github.com/muesli/termenv"
+)
+
+// useColorProfile has ColorFg and the like convert colors to the given
+// profile, as they would while a program drawing to a terminal with those
+// colors runs, until the returned function is called.
+func useColorProfile(profile te.Profile) (restore func()) {
+	p := &Program{profile: profile}
+	addColorProgram(p)
+	return func() { removeColorProgram(p) }
+}
+
+func TestColorProfileFollowsPrograms(t *testing.T) {
+	stdout := colorProfile()
+
+	// A program drawing to a VirtualTerminal has every color.
+	outer := startTestProgram(t)
+	outer.run(func() Msg { return StepMsg(0) })
+	outer.expect(StepMsg(0))
+	if got := colorProfile(); got != te.TrueColor {
+		t.Errorf("running a program: got %v, want %v", got, te.TrueColor)
+	}
+
+	// Another program started while it's running, drawing somewhere
+	// without colors, takes over until it exits.
+	running, quit := make(chan struct{}), make(chan struct{})
+	inner := NewProgram(
+		func() (Model, Cmd) {
+			return 0, func() Msg { return StepMsg(0) }
+		},
+		func(msg Msg, m Model) (Model, Cmd) {
+			if _, ok := msg.(StepMsg); ok {
+				close(running)
+				return m, func() Msg {
+					<-quit
+					return Quit()
+				}
+			}
+			return m, nil
+		},
+		func(Model) string { return "" },
+		WithInput(nil),
+		WithOutput(ioutil.Discard),
+	)
+	errc := make(chan error, 1)
+	go func() { errc <- inner.Start() }()
+	<-running
+	if got := colorProfile(); got != te.Ascii {
+		t.Errorf("running a second program: got %v, want %v", got, te.Ascii)
+	}
+	close(quit)
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if got := colorProfile(); got != te.TrueColor {
+		t.Errorf("after the second program: got %v, want %v", got, te.TrueColor)
+	}
+
+	// With no program running, they're stdout's again.
+	outer.stop()
+	if got := colorProfile(); got != stdout {
+		t.Errorf("after both programs: got %v, want %v", got, stdout)
+	}
+}
+
+func TestGradient(t *testing.T) {
+	defer useColorProfile(te.TrueColor)()
+	red, blue := te.RGBColor("#ff0000"), te.RGBColor("#0000ff")
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{"one character", "a", "\x1b[38;2;255;0;0ma\x1b[0m"},
+		{"end to end", "aaaa", "\x1b[38;2;255;0;0ma\x1b[38;2;223;0;99ma\x1b[38;2;173;0;175ma\x1b[38;2;0;0;255ma\x1b[0m"},
+		{"wide runes", "日本", "\x1b[38;2;255;0;0m日\x1b[38;2;0;0;255m本\x1b[0m"},
+		{"empty", "", ""},
+
+		// Each line's reset at its end, and the next carries on from where
+		// it left off.
+		{"lines", "ab\ncd", "\x1b[38;2;255;0;0ma\x1b[38;2;223;0;99mb\x1b[0m\n\x1b[38;2;173;0;175mc\x1b[38;2;0;0;255md\x1b[0m"},
+		{"blank line", "\n", "\n"},
+
+		// Escape sequences are kept, but don't count as characters, and
+		// the color's set again after them in case they changed it.
+		{"escape sequences", "a\x1b[1mb\x1b[0mc", "\x1b[38;2;255;0;0ma\x1b[1m\x1b[38;2;202;0;136mb\x1b[0m\x1b[38;2;0;0;255mc\x1b[0m"},
+	}
+	for _, tt := range tests {
+		if got := Gradient(red, blue, tt.s); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGradientFewerColors(t *testing.T) {
+	red, orange := te.RGBColor("#ff0000"), te.RGBColor("#ff8700")
+
+	// With fewer colors to go round, neighbours share them, and a color's
+	// only set where it changes.
+	restore := useColorProfile(te.ANSI256)
+	got := Gradient(red, orange, "abcdef")
+	restore()
+	if want := "\x1b[38;5;196ma\x1b[38;5;202mbcd\x1b[38;5;208mef\x1b[0m"; got != want {
+		t.Errorf("256 colors: got %q, want %q", got, want)
+	}
+
+	restore = useColorProfile(te.ANSI)
+	got = Gradient(red, orange, "abcdef")
+	restore()
+	if want := "\x1b[91mabcdef\x1b[0m"; got != want {
+		t.Errorf("16 colors: got %q, want %q", got, want)
+	}
+
+	// Without colors there's no gradient at all.
+	restore = useColorProfile(te.Ascii)
+	got = Gradient(red, orange, "a\x1b[1mb\nc")
+	restore()
+	if want := "a\x1b[1mb\nc"; got != want {
+		t.Errorf("no colors: got %q, want %q", got, want)
+	}
+}
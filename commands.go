@@ -4,6 +4,7 @@ package tea
 // handy.
 
 import (
+	"context"
 	"time"
 )
 
@@ -16,6 +17,13 @@ import (
 // and the clock is at 12:34:20 then the next tick will happen at 12:35:00, 40
 // seconds later.
 //
+// If the program shuts down before the tick, the command gives up and nothing
+// is sent.
+//
+// The wait happens once the program runs the command, on the program's
+// clock, so calling the command yourself doesn't produce the tick message.
+// To change the message on its way to Update, use MapCmd.
+//
 // To produce the command, pass a duration and a function which returns
 // a message containing the time at which the tick occurred.
 //
@@ -26,16 +34,22 @@ import (
 //   })
 func Every(duration time.Duration, fn func(time.Time) Msg) Cmd {
 	return func() Msg {
-		n := time.Now()
-		d := n.Truncate(duration).Add(duration).Sub(n)
-		t := time.NewTimer(d)
-		return fn(<-t.C)
+		return clockCmdMsg(func(ctx context.Context, c Clock) Msg {
+			n := c.Now()
+			now, ok := sleep(ctx, c, n.Truncate(duration).Add(duration).Sub(n))
+			if !ok {
+				return nil
+			}
+			return fn(now)
+		})
 	}
 }
 
 // Tick produces a command at an interval independent of the system clock at
 // the given duration. That is, the timer begins when precisely when invoked,
-// and runs for its entire duration.
+// and runs for its entire duration. If the program shuts down first, the
+// command gives up and nothing is sent. As with Every, the wait happens once
+// the program runs the command.
 //
 // To produce the command, pass a duration and a function which returns
 // a message containing the time at which the tick occurred.
@@ -47,7 +61,97 @@ func Every(duration time.Duration, fn func(time.Time) Msg) Cmd {
 //   })
 func Tick(d time.Duration, fn func(time.Time) Msg) Cmd {
 	return func() Msg {
-		t := time.NewTimer(d)
-		return fn(<-t.C)
+		return clockCmdMsg(func(ctx context.Context, c Clock) Msg {
+			now, ok := sleep(ctx, c, d)
+			if !ok {
+				return nil
+			}
+			return fn(now)
+		})
+	}
+}
+
+// QuitIf is a command that quits the program if the given function reports
+// true at the time the command runs. Otherwise it does nothing.
+//
+//   cmd := QuitIf(func() bool {
+//       return m.done
+//   })
+func QuitIf(fn func() bool) Cmd {
+	return func() Msg {
+		if fn() {
+			return Quit()
+		}
+		return nil
+	}
+}
+
+// MapCmd returns a command that runs cmd and passes the message it produces
+// through fn before it's sent to Update. It's the way to wrap a command
+// without calling it yourself, which matters for commands such as Tick and
+// CmdWithContext whose messages are only produced once the program runs them.
+//
+//   cmd := MapCmd(fetch(url), func(msg Msg) Msg {
+//       return pageMsg{url: url, msg: msg}
+//   })
+//
+// Commands made with Batch are mapped one by one. fn isn't called when a
+// command produces no message, and special commands such as Quit are left
+// alone.
+func MapCmd(cmd Cmd, fn func(Msg) Msg) Cmd {
+	if cmd == nil || fn == nil {
+		return cmd
+	}
+	return mapCmd(cmd, func(msg Msg) Msg {
+		if isInternalMsg(msg) {
+			return msg
+		}
+		return fn(msg)
+	})
+}
+
+// mapCmd returns a command that runs cmd and maps its message with mapMsg.
+func mapCmd(cmd Cmd, fn func(Msg) Msg) Cmd {
+	return func() Msg {
+		return mapMsg(cmd(), fn)
+	}
+}
+
+// mapMsg applies fn to the message msg stands for. Most messages stand for
+// themselves, but the internal messages carrying commands stand for whatever
+// those commands produce once the program runs them, so fn is applied to that
+// instead. fn is never called with nil.
+//
+// Anything that wraps a command and needs to see its message has to go
+// through here, or it ends up looking at the carrier rather than the message.
+func mapMsg(msg Msg, fn func(Msg) Msg) Msg {
+	switch m := msg.(type) {
+	case nil:
+		return nil
+	case batchMsg:
+		cmds := make([]Cmd, len(m.cmds))
+		for i, c := range m.cmds {
+			cmds[i] = mapCmd(c, fn)
+		}
+		m.cmds = cmds
+		return m
+	case requestMsg:
+		m.cmd = mapCmd(m.cmd, fn)
+		return m
+	case contextCmdMsg:
+		return contextCmdMsg(func(ctx context.Context) Msg {
+			return mapMsg(m(ctx), fn)
+		})
+	case clockCmdMsg:
+		return clockCmdMsg(func(ctx context.Context, c Clock) Msg {
+			return mapMsg(m(ctx, c), fn)
+		})
+	case frameTickMsg:
+		tick := m.fn
+		m.fn = func(t time.Time) Msg {
+			return mapMsg(tick(t), fn)
+		}
+		return m
 	}
+	return fn(msg)
 }
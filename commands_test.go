@@ -0,0 +1,118 @@
+package tea
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TickedMsg is sent by the ticks in tests. It's exported so that it isn't
+// taken for one of the package's internal messages.
+type TickedMsg time.Time
+
+func tickFn(t time.Time) Msg { return TickedMsg(t) }
+
+func TestTickWrapped(t *testing.T) {
+	double := func(msg Msg) Msg {
+		return [2]Msg{msg, msg}
+	}
+
+	tests := []struct {
+		name  string
+		cmd   Cmd
+		timer int // number of timers started for the command
+		want  Msg
+	}{
+		{
+			name:  "Tick",
+			cmd:   Tick(time.Second, tickFn),
+			timer: 1,
+			want:  TickedMsg(time.Date(2020, 1, 1, 0, 0, 1, 0, time.UTC)),
+		},
+		{
+			name:  "Every",
+			cmd:   Every(time.Minute, tickFn),
+			timer: 1,
+			want:  TickedMsg(time.Date(2020, 1, 1, 0, 1, 0, 0, time.UTC)),
+		},
+		{
+			name:  "MapCmd",
+			cmd:   MapCmd(Tick(time.Second, tickFn), double),
+			timer: 1,
+			want:  [2]Msg{TickedMsg(time.Date(2020, 1, 1, 0, 0, 1, 0, time.UTC)), TickedMsg(time.Date(2020, 1, 1, 0, 0, 1, 0, time.UTC))},
+		},
+		{
+			name:  "Tag",
+			cmd:   Tag("a", Tick(time.Second, tickFn)),
+			timer: 1,
+			want:  TaggedMsg{ID: "a", Msg: TickedMsg(time.Date(2020, 1, 1, 0, 0, 1, 0, time.UTC))},
+		},
+		{
+			name:  "Tag in Batch",
+			cmd:   Batch(Tag("a", Tick(time.Second, tickFn)), nil),
+			timer: 1,
+			want:  TaggedMsg{ID: "a", Msg: TickedMsg(time.Date(2020, 1, 1, 0, 0, 1, 0, time.UTC))},
+		},
+		{
+			name:  "Batch in Tag",
+			cmd:   Tag("a", Batch(Tick(time.Second, tickFn), Tick(time.Hour, tickFn))),
+			timer: 2,
+			want:  TaggedMsg{ID: "a", Msg: TickedMsg(time.Date(2020, 1, 1, 0, 0, 1, 0, time.UTC))},
+		},
+		{
+			name: "CmdWithContext",
+			cmd: MapCmd(CmdWithContext(func(context.Context) Msg {
+				return "done"
+			}), double),
+			want: [2]Msg{"done", "done"},
+		},
+		{
+			name:  "FrameTick",
+			cmd:   Tag("a", FrameTick(time.Second, tickFn)),
+			timer: 1,
+			want:  TaggedMsg{ID: "a", Msg: TickedMsg(time.Date(2020, 1, 1, 0, 0, 1, 0, time.UTC))},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := startTestProgram(t)
+			defer p.stop()
+
+			p.run(tt.cmd)
+			if tt.timer > 0 {
+				p.advance(tt.timer, time.Minute)
+			}
+			p.expect(tt.want)
+		})
+	}
+}
+
+func TestMapCmdLeavesQuitAlone(t *testing.T) {
+	p := startTestProgram(t)
+	p.run(MapCmd(Quit, func(msg Msg) Msg {
+		t.Errorf("mapped %#v", msg)
+		return msg
+	}))
+
+	select {
+	case err := <-p.errc:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("program didn't quit")
+	}
+}
+
+func TestQuitIf(t *testing.T) {
+	for _, done := range []bool{false, true} {
+		var want Msg
+		if done {
+			want = quitMsg{}
+		}
+		if got := QuitIf(func() bool { return done })(); got != want {
+			t.Errorf("done = %v: got %#v, want %#v", done, got, want)
+		}
+	}
+}
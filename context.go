@@ -0,0 +1,36 @@
+package tea
+
+import "context"
+
+// CmdWithContext builds a command from a function that takes a context. When
+// the command runs, the function is passed a context that's cancelled when
+// the program shuts down, whether that's because it quit, was killed or
+// panicked. This makes it easy to hand off to APIs that accept a context,
+// such as net/http, and have them give up once the program's gone.
+//
+//   cmd := CmdWithContext(func(ctx context.Context) Msg {
+//       req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+//       res, err := http.DefaultClient.Do(req)
+//       if err != nil {
+//           return errMsg{err}
+//       }
+//       defer res.Body.Close()
+//       return statusMsg(res.StatusCode)
+//   })
+//
+// Cancellation is cooperative: the function keeps running until it notices
+// the context is done and returns. If the program has already shut down when
+// the command runs, the function is still called, but with a context that's
+// already cancelled. Any message returned after shutdown is discarded.
+func CmdWithContext(fn func(context.Context) Msg) Cmd {
+	if fn == nil {
+		return nil
+	}
+	return func() Msg {
+		return contextCmdMsg(fn)
+	}
+}
+
+// contextCmdMsg is an internal message carrying a command that needs the
+// program's context. You can send a contextCmdMsg with CmdWithContext.
+type contextCmdMsg func(context.Context) Msg
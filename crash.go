@@ -0,0 +1,81 @@
+package tea
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+	"time"
+)
+
+// CrashReportLevel determines how much detail goes into a crash report. See
+// WithCrashReport.
+type CrashReportLevel int
+
+// Available crash report levels.
+const (
+	// CrashReportBasic includes the panic and the stack trace.
+	CrashReportBasic CrashReportLevel = iota
+
+	// CrashReportFull also includes the message being processed when the
+	// panic occurred and the last view rendered to the terminal.
+	CrashReportFull
+)
+
+// cmdPanicMsg is an internal message which carries a panic from a command's
+// goroutine back to the event loop, so it can be handled like a panic in
+// Update.
+type cmdPanicMsg struct {
+	value  interface{}
+	stack  []byte
+	origin origin
+}
+
+// recoverFromCmdPanic recovers from a panic in a command with the given origin
+// and sends it to the event loop. It must be deferred.
+func (p *Program) recoverFromCmdPanic(o origin) {
+	if r := recover(); r != nil {
+		p.msgs <- cmdPanicMsg{value: r, stack: debug.Stack(), origin: o}
+	}
+}
+
+// writeCrashReport writes details about a panic to the crash report file, if
+// one was configured. If the panic happened in a command, cmdOrigin describes
+// where that command came from.
+func (p *Program) writeCrashReport(r interface{}, stack []byte, cmdOrigin *CmdOrigin) {
+	if p.crashReportPath == "" {
+		return
+	}
+
+	f, err := os.OpenFile(p.crashReportPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	fmt.Fprintf(f, "Caught panic at %s:\n\n%v\n\n", time.Now().Format(time.RFC3339), r)
+
+	if cmdOrigin != nil {
+		fmt.Fprintf(f, "Panic occurred in a command returned by %s\n\n", cmdOrigin)
+	}
+
+	if p.crashReportLevel >= CrashReportFull {
+		fmt.Fprintf(f, "Message being processed:\n\n%#v\n\n", p.currentMsg)
+
+		var view string
+		if p.renderer != nil {
+			p.mtx.Lock()
+			view = string(p.renderer.lastRender)
+			p.mtx.Unlock()
+		}
+		fmt.Fprintf(f, "Last rendered view:\n\n%s\n\n", view)
+	}
+
+	if frames := p.RecentFrames(); len(frames) > 0 {
+		fmt.Fprintf(f, "Recent frames, oldest first:\n\n")
+		for _, fr := range frames {
+			fmt.Fprintf(f, "At %s:\n\n%s\n\n", fr.Time.Format(time.RFC3339Nano), fr.View)
+		}
+	}
+
+	fmt.Fprintf(f, "Stack trace:\n\n%s", stack)
+}
@@ -0,0 +1,101 @@
+package tea
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	te "github.com/muesli/termenv"
+)
+
+// deviceAttributesTimeout is how long to wait for the terminal to answer a
+// device attributes query before giving up on it.
+const deviceAttributesTimeout = time.Second
+
+// DeviceAttributesMsg is sent in response to RequestDeviceAttributes. It holds
+// the terminal's primary device attributes, which describe the kind of
+// terminal it is and some of the features it supports. The first attribute
+// is the terminal's conformance level, such as 62 for VT220 or 64 for VT420;
+// the rest are features, such as 4 for sixel graphics or 22 for ANSI color.
+//
+// If the terminal doesn't answer in time, Attributes is empty and TimedOut
+// is set.
+type DeviceAttributesMsg struct {
+	Attributes []int
+	TimedOut   bool
+}
+
+// Has reports whether the terminal reported the given attribute.
+func (m DeviceAttributesMsg) Has(attr int) bool {
+	for _, a := range m.Attributes {
+		if a == attr {
+			return true
+		}
+	}
+	return false
+}
+
+// RequestDeviceAttributes is a special command that asks the terminal for its
+// primary device attributes. The answer arrives as a DeviceAttributesMsg.
+func RequestDeviceAttributes() Msg {
+	return requestDeviceAttributesMsg{}
+}
+
+// requestDeviceAttributesMsg is an internal message that queries the
+// terminal's device attributes. You can send a requestDeviceAttributesMsg with
+// RequestDeviceAttributes.
+type requestDeviceAttributesMsg struct{}
+
+// deviceAttributesTimeoutMsg is an internal message signalling that the
+// terminal hasn't answered a device attributes query in time.
+type deviceAttributesTimeoutMsg struct {
+	seq uint64
+}
+
+// requestDeviceAttributes sends a device attributes query to the terminal and
+// arranges for a DeviceAttributesMsg to be sent if it doesn't answer in time.
+// It's only called from the event loop.
+func (p *Program) requestDeviceAttributes() {
+	p.mtx.Lock()
+	_, _ = p.output.Write([]byte(te.CSI + "c"))
+	p.mtx.Unlock()
+
+	p.daRequested++
+	seq := p.daRequested
+	t := p.clock.NewTimer(deviceAttributesTimeout)
+	go func() {
+		defer t.Stop()
+		select {
+		case <-t.C():
+		case <-p.done:
+			return
+		}
+		select {
+		case p.msgs <- deviceAttributesTimeoutMsg{seq: seq}:
+		case <-p.done:
+		}
+	}()
+}
+
+// parseDeviceAttributes parses a primary device attributes report, which
+// looks like ESC [ ? 64 ; 1 ; 2 c.
+func parseDeviceAttributes(buf []byte) (DeviceAttributesMsg, bool) {
+	s := string(buf)
+	if !strings.HasPrefix(s, te.CSI+"?") || !strings.HasSuffix(s, "c") {
+		return DeviceAttributesMsg{}, false
+	}
+	s = s[len(te.CSI+"?") : len(s)-1]
+
+	var m DeviceAttributesMsg
+	for _, param := range strings.Split(s, ";") {
+		if param == "" {
+			continue
+		}
+		a, err := strconv.Atoi(param)
+		if err != nil {
+			return DeviceAttributesMsg{}, false
+		}
+		m.Attributes = append(m.Attributes, a)
+	}
+	return m, true
+}
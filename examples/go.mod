@@ -6,11 +6,10 @@ require (
 	github.com/charmbracelet/bubbles v0.6.1
 	github.com/charmbracelet/bubbletea v0.11.1
 	github.com/fogleman/ease v0.0.0-20170301025033-8da417bf1776
-	github.com/lucasb-eyer/go-colorful v1.0.3
-	github.com/mattn/go-runewidth v0.0.9
+	github.com/lucasb-eyer/go-colorful v1.2.0
+	github.com/mattn/go-runewidth v0.0.14
 	github.com/muesli/reflow v0.1.0
-	github.com/muesli/termenv v0.7.4
-	golang.org/x/sys v0.0.0-20201014080544-cc95f250f6bc // indirect
+	github.com/muesli/termenv v0.13.0
 )
 
 replace github.com/charmbracelet/bubbletea => ../
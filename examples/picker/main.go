@@ -0,0 +1,155 @@
+package main
+
+// A status bar that runs a full-screen picker on the same terminal. The two
+// programs share a terminal handle: the status bar yields the terminal while
+// the picker is open, and gets it back, and redraws, when the picker exits.
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var (
+	terminal = tea.NewTerminalHandle()
+	flavors  = []string{"Vanilla", "Chocolate", "Strawberry", "Pistachio", "Mint"}
+)
+
+type statusModel struct {
+	flavor string
+	uptime time.Duration
+}
+
+type tickMsg struct{}
+
+type pickedMsg struct {
+	flavor string
+	err    error
+}
+
+func main() {
+	p := tea.NewProgram(initStatus, updateStatus, viewStatus, tea.WithTerminalHandle(terminal))
+	if err := p.Start(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func initStatus() (tea.Model, tea.Cmd) {
+	return statusModel{flavor: "none"}, tick()
+}
+
+func updateStatus(message tea.Msg, mdl tea.Model) (tea.Model, tea.Cmd) {
+	m, _ := mdl.(statusModel)
+
+	switch msg := message.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		case "p":
+			return m, pick
+		}
+
+	case tickMsg:
+		m.uptime += time.Second
+		return m, tick()
+
+	case pickedMsg:
+		if msg.err != nil {
+			log.Println(msg.err)
+			return m, nil
+		}
+		if msg.flavor != "" {
+			m.flavor = msg.flavor
+		}
+	}
+
+	return m, nil
+}
+
+func viewStatus(mdl tea.Model) string {
+	m, _ := mdl.(statusModel)
+	return fmt.Sprintf("flavor: %s | up %s | p: pick, q: quit", m.flavor, m.uptime)
+}
+
+func tick() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return tickMsg{}
+	})
+}
+
+// pick runs the picker in its own program. It yields the terminal first; once
+// the picker exits, the terminal goes back to the status bar by itself.
+func pick() tea.Msg {
+	if err := terminal.Yield(); err != nil {
+		return pickedMsg{err: err}
+	}
+
+	// The picker sends its choice along this channel when it's done.
+	choice := make(chan string, 1)
+	picker := tea.NewProgram(initPicker(choice), updatePicker, viewPicker,
+		tea.WithTerminalHandle(terminal),
+		tea.WithStartupBehavior(tea.StartupAltScreen),
+	)
+	if err := picker.Start(); err != nil {
+		return pickedMsg{err: err}
+	}
+
+	select {
+	case flavor := <-choice:
+		return pickedMsg{flavor: flavor}
+	default:
+		return pickedMsg{}
+	}
+}
+
+type pickerModel struct {
+	cursor int
+	choice chan string
+}
+
+func initPicker(choice chan string) func() (tea.Model, tea.Cmd) {
+	return func() (tea.Model, tea.Cmd) {
+		return pickerModel{choice: choice}, nil
+	}
+}
+
+func updatePicker(message tea.Msg, mdl tea.Model) (tea.Model, tea.Cmd) {
+	m, _ := mdl.(pickerModel)
+
+	if msg, ok := message.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(flavors)-1 {
+				m.cursor++
+			}
+		case "enter":
+			m.choice <- flavors[m.cursor]
+			return m, tea.Quit
+		case "esc", "q":
+			return m, tea.Quit
+		}
+	}
+
+	return m, nil
+}
+
+func viewPicker(mdl tea.Model) string {
+	m, _ := mdl.(pickerModel)
+
+	s := "\n  Pick a flavor:\n\n"
+	for i, f := range flavors {
+		cursor := " "
+		if i == m.cursor {
+			cursor = ">"
+		}
+		s += fmt.Sprintf("  %s %s\n", cursor, f)
+	}
+	return s + "\n  enter: choose, esc: cancel\n"
+}
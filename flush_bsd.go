@@ -0,0 +1,18 @@
+// +build darwin dragonfly freebsd netbsd openbsd
+
+package tea
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// fread tells TIOCFLUSH to flush the input queue only.
+const fread = 0x1
+
+// flushInput discards any data which has been received by the terminal but
+// not yet read.
+func flushInput(f *os.File) error {
+	return unix.IoctlSetPointerInt(int(f.Fd()), unix.TIOCFLUSH, fread)
+}
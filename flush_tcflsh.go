@@ -0,0 +1,15 @@
+// +build linux solaris
+
+package tea
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// flushInput discards any data which has been received by the terminal but
+// not yet read.
+func flushInput(f *os.File) error {
+	return unix.IoctlSetInt(int(f.Fd()), unix.TCFLSH, unix.TCIFLUSH)
+}
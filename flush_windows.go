@@ -0,0 +1,20 @@
+// +build windows
+
+package tea
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+var procFlushConsoleInputBuffer = windows.NewLazySystemDLL("kernel32.dll").NewProc("FlushConsoleInputBuffer")
+
+// flushInput discards any input events in the console's input buffer.
+func flushInput(f *os.File) error {
+	r, _, err := procFlushConsoleInputBuffer.Call(f.Fd())
+	if r == 0 {
+		return err
+	}
+	return nil
+}
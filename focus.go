@@ -0,0 +1,29 @@
+package tea
+
+// FocusMsg is sent when the terminal gains focus. It's only sent if focus
+// reporting has been enabled with WithReportFocus, and only by terminals that
+// support focus reporting.
+type FocusMsg struct{}
+
+// BlurMsg is sent when the terminal loses focus. Like FocusMsg, it's only
+// sent when focus reporting is enabled.
+type BlurMsg struct{}
+
+// HideCursor is a special command that hides the cursor. The cursor is hidden
+// by default.
+func HideCursor() Msg {
+	return hideCursorMsg{}
+}
+
+// ShowCursor is a special command that shows the cursor.
+func ShowCursor() Msg {
+	return showCursorMsg{}
+}
+
+// hideCursorMsg is an internal message that tells the renderer to hide the
+// cursor. You can send a hideCursorMsg with HideCursor.
+type hideCursorMsg struct{}
+
+// showCursorMsg is an internal message that tells the renderer to show the
+// cursor. You can send a showCursorMsg with ShowCursor.
+type showCursorMsg struct{}
@@ -0,0 +1,148 @@
+package tea
+
+import "time"
+
+// FrameMsg is sent once per frame while frame messages are on. Turn them on
+// with WithFrameMessages or EnableFrameMessages.
+//
+// Frames aren't always exactly the same length apart, and if the program's
+// busy some won't be reported at all, so animations should work out where
+// things are from Delta rather than assume a fixed frame rate:
+//
+//   case FrameMsg:
+//       m.x += m.speed * msg.Delta.Seconds()
+type FrameMsg struct {
+	Number uint64        // frame number, counting from zero when frame messages were turned on
+	Time   time.Time     // when the frame was rendered
+	Delta  time.Duration // time since the previous FrameMsg, or zero for the first
+}
+
+// EnableFrameMessages is a special command that turns on frame messages. See
+// FrameMsg.
+func EnableFrameMessages() Msg {
+	return enableFrameMsgsMsg{}
+}
+
+// DisableFrameMessages is a special command that turns off frame messages.
+// A frame message which is already on its way may still arrive.
+func DisableFrameMessages() Msg {
+	return disableFrameMsgsMsg{}
+}
+
+// FrameTick is like Tick, but for animations. As well as waiting for the given
+// duration, it waits for the view from the update that returned it to be
+// drawn. An animation that returns another FrameTick each time it gets a
+// message can then never get ahead of what the terminal can draw: no Update
+// or View calls are wasted on frames that are never seen, and when the
+// terminal's slow the animation slows down with it rather than skipping
+// frames. When the terminal keeps up, ticks come at the given interval.
+//
+//   case spinMsg:
+//       m.frame++
+//       return m, tea.FrameTick(time.Second/30, func(time.Time) tea.Msg {
+//           return spinMsg{}
+//       })
+//
+// If the program shuts down before the tick, the command gives up and nothing
+// is sent.
+func FrameTick(d time.Duration, fn func(time.Time) Msg) Cmd {
+	return func() Msg {
+		return frameTickMsg{d: d, fn: fn}
+	}
+}
+
+// frameTickMsg is an internal message carrying a tick which waits for a frame
+// to be drawn. You can send a frameTickMsg with FrameTick.
+type frameTickMsg struct {
+	d  time.Duration
+	fn func(time.Time) Msg
+}
+
+// frameTick waits for the tick t and for the given frame to be drawn, and then
+// returns the tick's message. It returns nil if the program shuts down first.
+func (p *Program) frameTick(t frameTickMsg, frame uint64) Msg {
+	timer := p.clock.NewTimer(t.d)
+	defer timer.Stop()
+
+	if !p.renderer.waitDrawn(p.ctx, frame) {
+		return nil
+	}
+	select {
+	case now := <-timer.C():
+		return t.fn(now)
+	case <-p.ctx.Done():
+		return nil
+	}
+}
+
+// enableFrameMsgsMsg is an internal message that tells the renderer to send
+// frame messages. You can send an enableFrameMsgsMsg with EnableFrameMessages.
+type enableFrameMsgsMsg struct{}
+
+// disableFrameMsgsMsg is an internal message that tells the renderer to stop
+// sending frame messages. You can send a disableFrameMsgsMsg with
+// DisableFrameMessages.
+type disableFrameMsgsMsg struct{}
+
+// setFrameMsgs turns frame messages on or off.
+func (r *renderer) setFrameMsgs(on bool) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.frameMsgs = on
+	r.frameNum = 0
+	if !on {
+		// Drop anything still waiting to be sent.
+		select {
+		case <-r.frames:
+		default:
+		}
+	}
+}
+
+// reportFrame queues a FrameMsg for the frame just rendered, if frame
+// messages are on. If the program hasn't picked up the last one yet it's
+// replaced, so a slow program only ever has the latest frame waiting for it.
+func (r *renderer) reportFrame() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if !r.frameMsgs {
+		return
+	}
+
+	msg := FrameMsg{Number: r.frameNum, Time: r.clock.Now()}
+	r.frameNum++
+
+	// We only send while holding the lock, so once the old message is
+	// out of the way there's room for this one.
+	select {
+	case <-r.frames:
+	default:
+	}
+	r.frames <- msg
+}
+
+// forwardFrames sends frame messages to the program as it's ready for them,
+// until the program shuts down. Delta is filled in here, as the time since
+// the last frame the program actually received.
+func (p *Program) forwardFrames() {
+	var last time.Time
+	for {
+		select {
+		case <-p.done:
+			return
+		case msg := <-p.renderer.frames:
+			if msg.Number > 0 {
+				msg.Delta = msg.Time.Sub(last)
+			}
+			last = msg.Time
+
+			select {
+			case p.msgs <- msg:
+			case <-p.done:
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package tea
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// throttledWriter is an output that takes a while to write to, like a slow
+// terminal.
+type throttledWriter struct {
+	delay time.Duration
+	mtx   sync.Mutex
+}
+
+func (w *throttledWriter) Write(b []byte) (int, error) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	time.Sleep(w.delay)
+	return len(b), nil
+}
+
+// runAnimation runs an animation chained on FrameTick, as fast as it'll go,
+// for about the given time with each write to the terminal taking delay. It
+// returns the number of views asked for, the program's metrics at the end
+// and how long it ran for.
+func runAnimation(t *testing.T, run, delay time.Duration) (int, ProgramMetrics, time.Duration) {
+	t.Helper()
+	var views int32
+	next := FrameTick(time.Millisecond, func(time.Time) Msg { return StepMsg(0) })
+	p := NewProgram(
+		func() (Model, Cmd) { return 0, next },
+		func(msg Msg, m Model) (Model, Cmd) {
+			if _, ok := msg.(StepMsg); ok {
+				return m.(int) + 1, next
+			}
+			return m, nil
+		},
+		func(m Model) string {
+			atomic.AddInt32(&views, 1)
+			return strconv.Itoa(m.(int))
+		},
+		WithInput(nil),
+		WithOutput(&throttledWriter{delay: delay}),
+	)
+	start := time.Now()
+	time.AfterFunc(run, func() { p.Send(Quit()) })
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return int(atomic.LoadInt32(&views)), p.Metrics(), time.Since(start)
+}
+
+func TestFrameTickThrottled(t *testing.T) {
+	const (
+		run   = 400 * time.Millisecond
+		delay = 50 * time.Millisecond // a few frames' worth
+	)
+	fast, _, _ := runAnimation(t, run, 0)
+	slow, m, elapsed := runAnimation(t, run, delay)
+
+	// Views are only asked for as fast as they can be written, and hardly
+	// any go to waste.
+	if max := int(elapsed/delay) + 2; slow > max {
+		t.Errorf("%d views in %s, want at most %d", slow, elapsed, max)
+	}
+	if slow >= fast {
+		t.Errorf("%d views with a slow terminal, %d with a fast one", slow, fast)
+	}
+	if m.DroppedFrames > 1 {
+		t.Errorf("%d of %d frames dropped", m.DroppedFrames, m.TotalFrames)
+	}
+	if drawn := m.TotalRenders + m.SkippedRenders + m.DroppedFrames; drawn < m.TotalFrames {
+		t.Errorf("%d frames, but only %d drawn", m.TotalFrames, m.TotalRenders)
+	}
+}
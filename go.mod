@@ -3,8 +3,9 @@ module github.com/charmbracelet/bubbletea
 go 1.13
 
 require (
-	github.com/containerd/console v1.0.1
-	github.com/muesli/termenv v0.7.2
+	github.com/fsnotify/fsnotify v1.4.9
+	github.com/mattn/go-runewidth v0.0.14
+	github.com/muesli/termenv v0.13.0
 	golang.org/x/crypto v0.0.0-20201012173705-84dcc777aaee
-	golang.org/x/sys v0.0.0-20201009025420-dfb3f7c4e634
+	golang.org/x/sys v0.0.0-20220811171246-fbc7d0a398ab
 )
@@ -0,0 +1,126 @@
+package tea
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrTerminalInUse is returned when starting a program with a TerminalHandle
+// that another program owns and hasn't yielded.
+var ErrTerminalInUse = errors.New("terminal is in use by another program")
+
+// ErrTerminalNotOwned is returned when yielding a TerminalHandle that no
+// running program owns.
+var ErrTerminalNotOwned = errors.New("terminal is not owned by a program")
+
+// ErrTerminalYielded is returned when yielding a TerminalHandle that's already
+// been yielded.
+var ErrTerminalYielded = errors.New("terminal has already been yielded")
+
+// TerminalHandle lets programs take turns with the same terminal. Only one
+// program owns the terminal at a time. The owner can Yield it, which releases
+// the terminal as ReleaseTerminal does, and another program can then start
+// and use it. When that program exits, the terminal goes back to the program
+// that yielded it, which draws its view again.
+//
+// For example, a program showing a status bar could run a picker like so:
+//
+//   h := tea.NewTerminalHandle()
+//   status := tea.NewProgram(..., tea.WithTerminalHandle(h))
+//
+//   // Later, from a command run by the status bar program:
+//   if err := h.Yield(); err != nil {
+//       return errMsg(err)
+//   }
+//   picker := tea.NewProgram(..., tea.WithTerminalHandle(h))
+//   err := picker.Start() // status gets the terminal back when this returns
+//
+// Programs that share a handle must use it for every turn they take. Starting
+// a program while the owner hasn't yielded fails with ErrTerminalInUse.
+type TerminalHandle struct {
+	mtx     sync.Mutex
+	owners  []*Program // programs waiting for the terminal, the owner last
+	yielded bool
+}
+
+// NewTerminalHandle returns a TerminalHandle for programs to share.
+func NewTerminalHandle() *TerminalHandle {
+	return &TerminalHandle{}
+}
+
+// Yield releases the terminal from the program that owns it so another
+// program can start with the handle. As with ReleaseTerminal, a read from the
+// input may already be underway when the terminal is yielded; whatever it
+// picks up is passed on to the program the terminal was yielded to.
+func (h *TerminalHandle) Yield() error {
+	h.mtx.Lock()
+	if len(h.owners) == 0 {
+		h.mtx.Unlock()
+		return ErrTerminalNotOwned
+	}
+	if h.yielded {
+		h.mtx.Unlock()
+		return ErrTerminalYielded
+	}
+	owner := h.owners[len(h.owners)-1]
+	h.yielded = true
+	h.mtx.Unlock()
+
+	if err := owner.ReleaseTerminal(); err != nil {
+		h.mtx.Lock()
+		h.yielded = false
+		h.mtx.Unlock()
+		return err
+	}
+	return nil
+}
+
+// acquire makes p the owner of the terminal, provided it's free.
+func (h *TerminalHandle) acquire(p *Program) error {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	if len(h.owners) > 0 && !h.yielded {
+		return ErrTerminalInUse
+	}
+	h.owners = append(h.owners, p)
+	h.yielded = false
+	return nil
+}
+
+// release gives up p's claim on the terminal. If p was the owner, the
+// terminal goes back to the program that yielded it to p.
+func (h *TerminalHandle) release(p *Program) {
+	h.mtx.Lock()
+	var prev *Program
+	for i, o := range h.owners {
+		if o != p {
+			continue
+		}
+		if i == len(h.owners)-1 && i > 0 {
+			prev = h.owners[i-1]
+			h.yielded = false
+		}
+		h.owners = append(h.owners[:i], h.owners[i+1:]...)
+		break
+	}
+	if len(h.owners) == 0 {
+		h.yielded = false
+	}
+	h.mtx.Unlock()
+
+	if prev != nil {
+		_ = prev.RestoreTerminal()
+	}
+}
+
+// owner returns the program that currently owns the terminal, if any.
+func (h *TerminalHandle) owner() *Program {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	if len(h.owners) == 0 || h.yielded {
+		return nil
+	}
+	return h.owners[len(h.owners)-1]
+}
@@ -0,0 +1,69 @@
+package tea
+
+import "reflect"
+
+// WithHandler sends messages of the same type as msg to update instead of
+// the program's Update function. It can be given any number of times, once
+// for each type of message, to split a large Update up:
+//
+//   p := tea.NewProgram(initialize, update, view,
+//       tea.WithHandler(tea.KeyMsg{}, updateKeys),
+//       tea.WithHandler(tea.MouseMsg{}, updateMouse),
+//   )
+//
+// Messages without a handler of their own go to the program's Update
+// function. If that's nil, they're dropped without Update being called or the
+// view being rendered, which saves work when most messages, such as frequent
+// mouse motion, are of no interest:
+//
+//   p := tea.NewProgram(initialize, nil, view,
+//       tea.WithHandler(tea.KeyMsg{}, updateKeys),
+//       tea.WithHandler(dataMsg{}, updateData),
+//   )
+//
+// Messages are handled in the order they arrive whichever function they go
+// to. See also SetHandler.
+func WithHandler(msg Msg, update Update) ProgramOption {
+	return func(p *Program) {
+		p.setHandler(msg, update)
+	}
+}
+
+// SetHandler sends messages of the same type as msg to update, as WithHandler
+// does, replacing any handler for that type already set. A nil update
+// removes the handler, so the messages go to the program's Update function
+// again. Like SetUpdate, the change takes effect with the next message
+// processed.
+//
+// SetHandler is safe to call from any goroutine, including from within
+// Update.
+func (p *Program) SetHandler(msg Msg, update Update) {
+	p.funcsMtx.Lock()
+	defer p.funcsMtx.Unlock()
+	p.setHandler(msg, update)
+}
+
+// setHandler does the work for WithHandler and SetHandler. funcsMtx must be
+// held when calling this on a running program.
+func (p *Program) setHandler(msg Msg, update Update) {
+	t := reflect.TypeOf(msg)
+	if update == nil {
+		delete(p.handlers, t)
+		return
+	}
+	if p.handlers == nil {
+		p.handlers = make(map[reflect.Type]Update)
+	}
+	p.handlers[t] = update
+}
+
+// updateFor returns the function msg should be sent to, or nil if it
+// shouldn't be sent anywhere.
+func (p *Program) updateFor(msg Msg) Update {
+	p.funcsMtx.RLock()
+	defer p.funcsMtx.RUnlock()
+	if update, ok := p.handlers[reflect.TypeOf(msg)]; ok {
+		return update
+	}
+	return p.update
+}
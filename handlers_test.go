@@ -0,0 +1,91 @@
+package tea
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// handlerProgram starts a program with no Update, handling only key presses.
+// For each one it sends keys the number of times the view's been drawn so
+// far.
+func handlerProgram(keys chan<- int32) (*Program, chan error) {
+	var views int32
+	p := NewProgram(
+		func() (Model, Cmd) { return 0, nil },
+		nil,
+		func(Model) string {
+			atomic.AddInt32(&views, 1)
+			return "test"
+		},
+		WithInput(nil),
+		WithOutput(NewVirtualTerminal(80, 24)),
+		WithHandler(KeyMsg{}, func(msg Msg, m Model) (Model, Cmd) {
+			if k := msg.(KeyMsg); k.Type == KeyCtrlC {
+				return m, Quit
+			}
+			keys <- atomic.LoadInt32(&views)
+			return m, nil
+		}),
+	)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- p.Start()
+	}()
+	return p, errc
+}
+
+// sendKey sends a key press to a handlerProgram, waits for it to be handled
+// and returns the number of times the view had been drawn by then.
+func sendKey(t testing.TB, p *Program, keys <-chan int32, r rune) int32 {
+	p.Send(KeyMsg{Type: KeyRune, Rune: r})
+	select {
+	case n := <-keys:
+		return n
+	case <-time.After(testTimeout):
+		t.Fatalf("key %q wasn't handled", r)
+		return 0
+	}
+}
+
+// stopHandlerProgram quits a handlerProgram and waits for it to finish.
+func stopHandlerProgram(t testing.TB, p *Program, errc <-chan error) {
+	p.Send(KeyMsg{Type: KeyCtrlC})
+	select {
+	case <-errc:
+	case <-time.After(testTimeout):
+		t.Error("program didn't quit")
+	}
+}
+
+func TestHandlerIgnoredMsgs(t *testing.T) {
+	keys := make(chan int32)
+	p, errc := handlerProgram(keys)
+	defer stopHandlerProgram(t, p, errc)
+
+	before := sendKey(t, p, keys, 'a')
+	for i := 0; i < 1000; i++ {
+		p.Send(MouseMsg{X: i % 80, Y: i % 24, Type: MouseMotion})
+	}
+	after := sendKey(t, p, keys, 'b')
+
+	// The mouse motion went nowhere, so only the first key press drew the
+	// view.
+	if got := after - before; got != 1 {
+		t.Errorf("view drawn %d times, want 1", got)
+	}
+}
+
+func BenchmarkHandlerIgnoredMsgs(b *testing.B) {
+	keys := make(chan int32)
+	p, errc := handlerProgram(keys)
+	defer stopHandlerProgram(b, p, errc)
+	sendKey(b, p, keys, 'a')
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.Send(MouseMsg{X: i % 80, Y: i % 24, Type: MouseMotion})
+	}
+	sendKey(b, p, keys, 'b')
+}
@@ -0,0 +1,142 @@
+package tea
+
+import (
+	"context"
+	"fmt"
+)
+
+// RunHeadless runs a program without a terminal, for using a model as a plain
+// state machine in scripts and non-interactive modes. Init is called, then
+// each of msgs is sent to Update in turn, and the final model is returned.
+//
+// Commands are run just as they would be otherwise, but one at a time, in the
+// order they're returned, with every command from one message, and every
+// command from its results, run to completion before the next message is
+// sent. Nothing is rendered and there's no input. Asking for input gets an
+// answer straight away: ReadLine sends a LineMsg with EOF set, and
+// RequestDeviceAttributes a DeviceAttributesMsg with TimedOut set. Timers
+// started with StartTimer never tick, and other commands which only affect
+// the terminal are ignored.
+//
+// Quit ends the run early, with the model as it stands. A command that never
+// returns, such as one waiting on events that don't come, keeps RunHeadless
+// from returning too. If Init, Update or a command panics, the panic is
+// returned as an error, and if Init or Update returns a nil model, the run
+// ends with an error wrapping ErrNilModel.
+func RunHeadless(init Init, update Update, msgs []Msg) (Model, error) {
+	return runHeadless(init, update, msgs, nil)
+}
+
+// runHeadless does the work for RunHeadless, calling observe, if it isn't nil,
+// with the model after Init and after each update.
+func runHeadless(init Init, update Update, msgs []Msg, observe func(Model)) (model Model, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("bubbletea: caught panic: %v", r)
+		}
+	}()
+
+	h := headless{update: update, observe: observe, clock: systemClock{}}
+	h.ctx, h.cancel = context.WithCancel(context.Background())
+	defer h.cancel()
+
+	var cmd Cmd
+	h.model, cmd = init()
+	if h.model == nil {
+		return nil, fmt.Errorf("Init returned a %w", ErrNilModel)
+	}
+	h.observed()
+	h.pending = append(h.pending, cmd)
+	h.settle()
+	for _, msg := range msgs {
+		if h.quit {
+			break
+		}
+		h.handle(msg)
+		h.settle()
+	}
+	return h.model, h.err
+}
+
+// headless is the state of a program run with RunHeadless.
+type headless struct {
+	update  Update
+	observe func(Model)
+	model   Model
+	ctx     context.Context
+	cancel  context.CancelFunc
+	clock   Clock
+
+	// commands waiting to be run, whether the program has quit, and why, if
+	// it failed
+	pending []Cmd
+	quit    bool
+	err     error
+}
+
+// settle runs the commands waiting to be run, and any commands they lead to,
+// until there are none left or the program quits.
+func (h *headless) settle() {
+	for len(h.pending) > 0 && !h.quit {
+		cmd := h.pending[0]
+		h.pending = h.pending[1:]
+		if cmd != nil {
+			h.handle(cmd())
+		}
+	}
+}
+
+// handle deals with a message as the event loop would, sending it to Update
+// and queueing the command it returns.
+func (h *headless) handle(msg Msg) {
+	switch msg := msg.(type) {
+	case nil, cancelledMsg:
+		return
+	case quitMsg:
+		h.quit = true
+		h.cancel()
+		return
+	case contextCmdMsg:
+		h.handle(msg(h.ctx))
+		return
+	case clockCmdMsg:
+		h.handle(msg(h.ctx, h.clock))
+		return
+	case frameTickMsg:
+		h.handle(Tick(msg.d, msg.fn)())
+		return
+	case batchMsg:
+		h.pending = append(h.pending, msg.cmds...)
+		return
+	case requestMsg:
+		h.pending = append(h.pending, msg.cmd)
+		return
+	case readLineMsg:
+		h.handle(LineMsg{EOF: true})
+		return
+	case requestDeviceAttributesMsg:
+		h.handle(DeviceAttributesMsg{TimedOut: true})
+		return
+	}
+	if isInternalMsg(msg) {
+		return
+	}
+
+	model, cmd := h.update(msg, h.model)
+	if model == nil {
+		h.err = fmt.Errorf("Update returned a %w for %T", ErrNilModel, msg)
+		h.quit = true
+		h.cancel()
+		return
+	}
+	h.model = model
+	h.observed()
+	h.pending = append(h.pending, cmd)
+}
+
+// observed passes the model to observe, if there is one.
+func (h *headless) observed() {
+	if h.observe != nil {
+		h.observe(h.model)
+	}
+}
@@ -0,0 +1,63 @@
+package tea
+
+import "time"
+
+// RecentFrame is a frame the renderer drew recently, as kept with
+// WithFrameHistory.
+type RecentFrame struct {
+	Time time.Time // when the frame was drawn
+	View string    // the view as it was drawn, cut short if it was too big to keep
+}
+
+// RecentFrames returns the frames drawn most recently, oldest first, if the
+// program was started WithFrameHistory. It's for building your own crash
+// reports and the like, and is safe to call from any goroutine.
+func (p *Program) RecentFrames() []RecentFrame {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if p.renderer == nil || p.renderer.history == nil {
+		return nil
+	}
+	return p.renderer.history.recent()
+}
+
+// frameHistory keeps the last few frames drawn, within limits on how many and
+// how many bytes. The frames are kept in a ring, starting at start.
+type frameHistory struct {
+	frames   []RecentFrame
+	start    int
+	n        int
+	bytes    int
+	maxBytes int
+}
+
+func newFrameHistory(maxFrames, maxBytes int) *frameHistory {
+	return &frameHistory{frames: make([]RecentFrame, maxFrames), maxBytes: maxBytes}
+}
+
+// add adds a frame drawn at time t, forgetting the oldest frames as needed to
+// make room. A frame too big to keep is cut short.
+func (h *frameHistory) add(t time.Time, view []byte) {
+	if len(view) > h.maxBytes {
+		view = view[:safeCut(view[:h.maxBytes])]
+	}
+	for h.n > 0 && (h.n == len(h.frames) || h.bytes+len(view) > h.maxBytes) {
+		h.bytes -= len(h.frames[h.start].View)
+		h.frames[h.start] = RecentFrame{}
+		h.start = (h.start + 1) % len(h.frames)
+		h.n--
+	}
+
+	h.frames[(h.start+h.n)%len(h.frames)] = RecentFrame{Time: t, View: string(view)}
+	h.n++
+	h.bytes += len(view)
+}
+
+// recent returns a copy of the frames kept, oldest first.
+func (h *frameHistory) recent() []RecentFrame {
+	frames := make([]RecentFrame, h.n)
+	for i := range frames {
+		frames[i] = h.frames[(h.start+i)%len(h.frames)]
+	}
+	return frames
+}
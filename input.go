@@ -0,0 +1,267 @@
+package tea
+
+import (
+	"bytes"
+	"io"
+	"sync/atomic"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// inputBufSize is the most we read from the input at once.
+	inputBufSize = 256
+
+	// defaultMaxPasteSize is how much of a bracketed paste we'll hold on to
+	// before giving up on it.
+	defaultMaxPasteSize = 1 << 20
+
+	// defaultPasteTimeout is how long we'll wait for more of a bracketed
+	// paste before deciding the end of it isn't coming.
+	defaultPasteTimeout = time.Second
+
+	// detectedPasteGap is how long a pause ends a paste we've detected.
+	detectedPasteGap = 10 * time.Millisecond
+)
+
+// Bracketed paste markers. When bracketed paste is on, the terminal wraps
+// pasted text in these so that it can't be mistaken for typing.
+var (
+	pasteStartSeq = []byte("\x1b[200~")
+	pasteEndSeq   = []byte("\x1b[201~")
+)
+
+// PasteMsg is sent when text is pasted into the terminal. It's only sent when
+// the program is created with WithBracketedPaste or WithPasteDetection;
+// otherwise pasted text arrives as keypresses, just like typing.
+type PasteMsg struct {
+	Text string
+
+	// Truncated is set when we didn't get the whole paste: either it was
+	// larger than the limit set with WithPasteLimits, or the terminal never
+	// marked the end of it. Text contains as much as we got.
+	Truncated bool
+}
+
+// readInputLoop reads input and sends messages for it until the program shuts
+// down or the input fails.
+func (p *Program) readInputLoop() {
+	chunks := make(chan []byte)
+	go func() {
+		defer p.inputReader.close()
+		for {
+			if !p.waitForInput() {
+				return
+			}
+			buf := make([]byte, inputBufSize)
+			n, err := p.inputReader.Read(buf)
+			if err == errReadCancelled {
+				// The terminal's been released, or the program's
+				// finishing.
+				continue
+			}
+			if err == io.EOF && p.readingLine() {
+				// The user ended input while a line was being read. That's
+				// the end of the line, not the end of all input.
+				err = nil
+			}
+			if err != nil {
+				select {
+				case p.errs <- err:
+				case <-p.done:
+				}
+				return
+			}
+			if n == 0 && !p.readingLine() {
+				continue
+			}
+			select {
+			case chunks <- buf[:n]:
+			case <-p.done:
+				if p.terminalHandle != nil {
+					// The terminal may have gone back to another
+					// program, in which case this is for it.
+					p.sendParsedInput(buf[:n])
+				}
+				return
+			}
+		}
+	}()
+
+	var (
+		pasting    bool
+		guessing   bool // pasting, but without bracketed paste to tell us so
+		discarding bool // paste was too big; wait for the end of it
+		paste      []byte
+		timer      Timer
+		timeout    <-chan time.Time
+	)
+
+	// waitForPaste (re)starts the wait for the rest of a paste.
+	waitForPaste := func(d time.Duration) {
+		if timer != nil {
+			timer.Stop()
+		}
+		timer = p.clock.NewTimer(d)
+		timeout = timer.C()
+	}
+
+	// endPaste sends what we have of the paste, if anything, and goes back to
+	// handling input as usual.
+	endPaste := func(truncated bool) {
+		if len(paste) > p.maxPasteSize {
+			paste, truncated = paste[:p.maxPasteSize], true
+		}
+		if !discarding {
+			p.sendInput(inputMsg{msg: PasteMsg{Text: string(paste), Truncated: truncated}, epoch: atomic.LoadUint32(&p.inputEpoch)})
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		pasting, guessing, discarding, paste, timer, timeout = false, false, false, nil, nil, nil
+	}
+
+	for {
+		var b []byte
+		select {
+		case <-p.done:
+			return
+		case <-timeout:
+			// A pause is how a paste we've guessed at ends. Otherwise the end
+			// of the paste isn't coming, so send what we got rather than
+			// leaving the program waiting forever.
+			endPaste(!guessing)
+			continue
+		case b = <-chunks:
+		}
+
+		if p.takeLine(b) {
+			continue
+		}
+
+		for len(b) > 0 {
+			if pasting {
+				// The end marker may have been split across reads, so look
+				// for it from just before the new input.
+				from := len(paste) - len(pasteEndSeq) + 1
+				if from < 0 {
+					from = 0
+				}
+				paste = append(paste, b...)
+				b = nil
+
+				if i := bytes.Index(paste[from:], pasteEndSeq); i >= 0 {
+					i += from
+					b = paste[i+len(pasteEndSeq):]
+					paste = paste[:i]
+					endPaste(false)
+					continue
+				}
+
+				// Allow for a partial end marker at the end when checking the
+				// size.
+				if !discarding && len(paste) > p.maxPasteSize+len(pasteEndSeq)-1 {
+					// Send as much as we're allowed and ignore the rest.
+					p.sendInput(inputMsg{
+						msg:   PasteMsg{Text: string(paste[:p.maxPasteSize]), Truncated: true},
+						epoch: atomic.LoadUint32(&p.inputEpoch),
+					})
+					discarding = true
+				}
+				if keep := len(pasteEndSeq) - 1; discarding && len(paste) > keep {
+					// Only hold on to enough to spot the end marker.
+					paste = append(paste[:0], paste[len(paste)-keep:]...)
+				}
+				waitForPaste(p.pasteTimeout)
+				continue
+			}
+
+			if guessing {
+				// Anything up to the start of a bracketed paste is more of
+				// the paste.
+				i := bytes.Index(b, pasteStartSeq)
+				if i < 0 {
+					i = len(b)
+				}
+				paste = append(paste, b[:i]...)
+				b = b[i:]
+				if !discarding && len(paste) > p.maxPasteSize {
+					// Send as much as we're allowed and ignore the rest.
+					p.sendInput(inputMsg{
+						msg:   PasteMsg{Text: string(paste[:p.maxPasteSize]), Truncated: true},
+						epoch: atomic.LoadUint32(&p.inputEpoch),
+					})
+					discarding = true
+				}
+				if discarding {
+					paste = paste[:0]
+				}
+				if len(b) > 0 {
+					endPaste(false)
+					continue
+				}
+				waitForPaste(detectedPasteGap)
+				continue
+			}
+
+			if i := bytes.Index(b, pasteStartSeq); i >= 0 {
+				switch {
+				case p.looksPasted(b[:i]):
+					p.sendInput(inputMsg{msg: PasteMsg{Text: string(b[:i])}, epoch: atomic.LoadUint32(&p.inputEpoch)})
+				case i > 0:
+					p.sendParsedInput(b[:i])
+				}
+				pasting = true
+				b = b[i+len(pasteStartSeq):]
+				waitForPaste(p.pasteTimeout)
+				continue
+			}
+
+			if p.looksPasted(b) {
+				guessing = true
+				continue
+			}
+
+			p.sendParsedInput(b)
+			b = nil
+		}
+	}
+}
+
+// sendParsedInput parses a chunk of input and sends the resulting message.
+func (p *Program) sendParsedInput(b []byte) {
+	// Answers to queries about the terminal's modes can come in the same
+	// read as keys typed after them, so send those separately.
+	if r, rest := parseModeReports(b); r != nil && len(rest) > 0 {
+		p.sendInput(inputMsg{msg: r, epoch: atomic.LoadUint32(&p.inputEpoch)})
+		b = rest
+	}
+
+	msg, err := parseInput(b)
+	if err != nil {
+		select {
+		case p.errs <- err:
+		case <-p.done:
+		}
+	}
+	p.sendInput(inputMsg{msg: msg, epoch: atomic.LoadUint32(&p.inputEpoch)})
+}
+
+// looksPasted reports whether a chunk of input has enough printable characters
+// in it to be taken for a paste, if we're guessing at pastes. Spaces, tabs and
+// line endings count as printable.
+func (p *Program) looksPasted(b []byte) bool {
+	if p.pasteThreshold <= 0 {
+		return false
+	}
+	var n int
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		if unicode.IsPrint(r) || r == '\t' || r == '\n' || r == '\r' {
+			n++
+		}
+		b = b[size:]
+	}
+	return n >= p.pasteThreshold
+}
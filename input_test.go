@@ -0,0 +1,64 @@
+package tea
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// startInputProgram starts a test program reading its input from a pipe,
+// returning the program and both ends of the pipe. Input's typed into w.
+func startInputProgram(t *testing.T, opts ...ProgramOption) (tp *testProgram, r, w *os.File) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return startTestProgram(t, append([]ProgramOption{WithInput(r)}, opts...)...), r, w
+}
+
+// typeInput writes s to the program's input.
+func typeInput(t *testing.T, w *os.File, s string) {
+	t.Helper()
+	if _, err := w.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPasteEscapeSequences(t *testing.T) {
+	tp, r, w := startInputProgram(t)
+	defer r.Close()
+	defer w.Close()
+	defer tp.stop()
+
+	// Escape sequences in a paste are part of the text, not keys.
+	typeInput(t, w, "\x1b[200~up\x1b[A\x1b[Bdown\x1b[201~")
+	tp.expect(PasteMsg{Text: "up\x1b[A\x1b[Bdown"})
+	tp.expectNone(50 * time.Millisecond)
+
+	// And once the paste is over they're keys again.
+	typeInput(t, w, "\x1b[A")
+	tp.expect(KeyMsg{Type: KeyUp})
+}
+
+func TestPasteWithoutEnd(t *testing.T) {
+	tp, r, w := startInputProgram(t)
+	defer r.Close()
+	defer w.Close()
+	defer tp.stop()
+
+	typeInput(t, w, "\x1b[200~half a paste")
+
+	// The program carries on while it waits for the rest of the paste.
+	tp.Send(StormMsg{})
+	tp.expect(StormMsg{})
+
+	// When the rest doesn't come, what there is of it is sent, and keys
+	// typed after that are keys.
+	tp.advance(1, defaultPasteTimeout)
+	tp.expect(PasteMsg{Text: "half a paste", Truncated: true})
+	typeInput(t, w, "x")
+	tp.expect(KeyMsg{Type: KeyRune, Rune: 'x'})
+	typeInput(t, w, "\x1b[A")
+	tp.expect(KeyMsg{Type: KeyUp})
+}
@@ -0,0 +1,100 @@
+package tea
+
+import (
+	"encoding/json"
+	"strings"
+
+	te "github.com/muesli/termenv"
+)
+
+// ColorTheme is the colors ColorizeJSON colors each part of a JSON document
+// with. Colors are given as for ColorFg; parts with no color are left as
+// they are.
+type ColorTheme struct {
+	Key    string
+	String string
+	Number string
+	Bool   string
+	Null   string
+}
+
+// DefaultColorTheme is a ColorTheme that works on light and dark backgrounds
+// alike, using colors from the basic 16 so that it matches the terminal's
+// own theme.
+var DefaultColorTheme = ColorTheme{
+	Key:    "12",
+	String: "2",
+	Number: "6",
+	Bool:   "3",
+	Null:   "8",
+}
+
+// ColorizeJSON highlights a JSON document, coloring its object keys, strings,
+// numbers, booleans and nulls as given by theme. The document is otherwise
+// left as it is, indentation and all, so format it first if it needs it:
+//
+//   b, _ := json.MarshalIndent(v, "", "  ")
+//   s, err := tea.ColorizeJSON(string(b), tea.DefaultColorTheme)
+//
+// If the document isn't valid JSON an error is returned. If the terminal
+// doesn't support color, the document is returned untouched. The colors the
+// terminal supports are worked out as for ColorFg.
+func ColorizeJSON(jsonStr string, theme ColorTheme) (string, error) {
+	if err := json.Unmarshal([]byte(jsonStr), new(json.RawMessage)); err != nil {
+		return "", err
+	}
+	if colorProfile() == te.Ascii {
+		return jsonStr, nil
+	}
+
+	var b strings.Builder
+	b.Grow(len(jsonStr) * 2)
+	for i := 0; i < len(jsonStr); {
+		// The document's valid, so the start of each token says what it is
+		// and finding its end is straightforward.
+		var end int
+		var color string
+		switch c := jsonStr[i]; {
+		case c == '"':
+			end = i + 1
+			for jsonStr[end] != '"' {
+				if jsonStr[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end++
+			color = theme.String
+			if rest := strings.TrimLeft(jsonStr[end:], " \t\r\n"); strings.HasPrefix(rest, ":") {
+				color = theme.Key
+			}
+		case c == '-' || (c >= '0' && c <= '9'):
+			end = i + strings.IndexFunc(jsonStr[i:], func(r rune) bool {
+				return !strings.ContainsRune("0123456789+-.eE", r)
+			})
+			if end < i {
+				end = len(jsonStr)
+			}
+			color = theme.Number
+		case c == 't':
+			end, color = i+len("true"), theme.Bool
+		case c == 'f':
+			end, color = i+len("false"), theme.Bool
+		case c == 'n':
+			end, color = i+len("null"), theme.Null
+		default:
+			// Punctuation and whitespace.
+			b.WriteByte(c)
+			i++
+			continue
+		}
+
+		if color == "" {
+			b.WriteString(jsonStr[i:end])
+		} else {
+			b.WriteString(ColorFg(color, jsonStr[i:end]))
+		}
+		i = end
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,76 @@
+package tea
+
+import (
+	"testing"
+
+	te "github.com/muesli/termenv"
+)
+
+func TestColorizeJSON(t *testing.T) {
+	defer useColorProfile(te.ANSI)()
+	theme := ColorTheme{Key: "1", String: "2", Number: "3", Bool: "4", Null: "5"}
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		// A string's a key if a colon follows it, however far away.
+		{"keys", `{"k" : "v"}`, "{\x1b[31m\"k\"\x1b[0m : \x1b[32m\"v\"\x1b[0m}"},
+		{"escaped quotes", `["a\"b", "c\\"]`, "[\x1b[32m\"a\\\"b\"\x1b[0m, \x1b[32m\"c\\\\\"\x1b[0m]"},
+		{"numbers", `[-1.5e+3, 0, 42]`, "[\x1b[33m-1.5e+3\x1b[0m, \x1b[33m0\x1b[0m, \x1b[33m42\x1b[0m]"},
+		{"number alone", `42`, "\x1b[33m42\x1b[0m"},
+		{"literals", `[true, false, null]`, "[\x1b[34mtrue\x1b[0m, \x1b[34mfalse\x1b[0m, \x1b[35mnull\x1b[0m]"},
+
+		// Layout's left as it is.
+		{
+			"indented", "{\n  \"a\": {\n    \"b\": []\n  }\n}",
+			"{\n  \x1b[31m\"a\"\x1b[0m: {\n    \x1b[31m\"b\"\x1b[0m: []\n  }\n}",
+		},
+	}
+	for _, tt := range tests {
+		got, err := ColorizeJSON(tt.doc, theme)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestColorizeJSONPartialTheme(t *testing.T) {
+	defer useColorProfile(te.ANSI)()
+
+	// Parts the theme has no color for are left alone.
+	got, err := ColorizeJSON(`{"a": 1, "b": "x"}`, ColorTheme{Key: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\x1b[31m\"a\"\x1b[0m: 1, \x1b[31m\"b\"\x1b[0m: \"x\"}"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestColorizeJSONInvalid(t *testing.T) {
+	defer useColorProfile(te.ANSI)()
+	for _, doc := range []string{`{"a": }`, `{"a": 1`, `"unterminated`, ``} {
+		if got, err := ColorizeJSON(doc, DefaultColorTheme); err == nil {
+			t.Errorf("%q: got %q, want an error", doc, got)
+		}
+	}
+}
+
+func TestColorizeJSONNoColor(t *testing.T) {
+	defer useColorProfile(te.Ascii)()
+
+	// Without colors the document comes back as it was, but it's still
+	// checked.
+	const doc = `{"a": [1, true, null, "x"]}`
+	if got, err := ColorizeJSON(doc, DefaultColorTheme); err != nil || got != doc {
+		t.Errorf("got %q, %v, want %q", got, err, doc)
+	}
+	if _, err := ColorizeJSON(`{"a": }`, DefaultColorTheme); err == nil {
+		t.Error("invalid document: got no error")
+	}
+}
@@ -3,7 +3,7 @@ package tea
 import (
 	"errors"
 	"fmt"
-	"io"
+	"strings"
 	"unicode/utf8"
 )
 
@@ -56,6 +56,38 @@ func (k *KeyMsg) String() (str string) {
 	return ""
 }
 
+// NewKeyMsg returns the KeyMsg with the given name, as produced by
+// KeyMsg.String. This is useful for simulating keypresses, such as in tests
+// or when replaying recorded input, and for reading key bindings from
+// configuration.
+//
+//     k, err := NewKeyMsg("ctrl+s")
+//     k, err = NewKeyMsg("alt+enter")
+//     k, err = NewKeyMsg("上")
+//
+// Any single rune is a KeyRune. Parsing the result of String gives back the
+// same key.
+func NewKeyMsg(s string) (KeyMsg, error) {
+	var k KeyMsg
+	if strings.HasPrefix(s, "alt+") && s != "alt+" {
+		k.Alt = true
+		s = s[len("alt+"):]
+	}
+
+	if t, ok := keyTypes[s]; ok && t != KeyRune {
+		k.Type = t
+		return k, nil
+	}
+
+	if r, size := utf8.DecodeRuneInString(s); size == len(s) && (r != utf8.RuneError || size > 1) {
+		k.Type = KeyRune
+		k.Rune = r
+		return k, nil
+	}
+
+	return KeyMsg{}, fmt.Errorf("unknown key %q", s)
+}
+
 // IsRune returns whether or not the key is a rune.
 func (k *KeyMsg) IsRune() bool {
 	return k.Type == KeyRune
@@ -236,6 +268,15 @@ var keyNames = map[int]string{
 	KeyPgDown:   "pgdown",
 }
 
+// Mapping for friendly names to consts. The reverse of keyNames.
+var keyTypes = func() map[string]KeyType {
+	m := make(map[string]KeyType, len(keyNames))
+	for k, name := range keyNames {
+		m[name] = KeyType(k)
+	}
+	return m
+}()
+
 // Mapping for sequences to consts.
 var sequences = map[string]KeyType{
 	"\x1b[A": KeyUp,
@@ -276,16 +317,39 @@ var hexes = map[string]Key{
 	"1b4f44": {Type: KeyLeft, Alt: false},
 }
 
-// readInput reads keypress and mouse input from a TTY and returns a message
-// containing information about the key or mouse event accordingly.
-func readInput(r io.Reader) (Msg, error) {
-	var buf [256]byte
+// Mapping for the keys terminals send SS3 sequences for in application cursor
+// mode (DECCKM) and application keypad mode (DECKPAM). Either mode may have
+// been left on by whatever ran before us, so these are accepted alongside the
+// usual sequences whatever mode the terminal's in. Keypad keys are taken for
+// the keys they're labelled with. The arrow keys are among the hexes above.
+var ss3Sequences = map[string]Key{
+	"\x1bOH": {Type: KeyHome},
+	"\x1bOF": {Type: KeyEnd},
+	"\x1bOM": {Type: KeyEnter},
+	"\x1bOj": {Type: KeyRune, Rune: '*'},
+	"\x1bOk": {Type: KeyRune, Rune: '+'},
+	"\x1bOl": {Type: KeyRune, Rune: ','},
+	"\x1bOm": {Type: KeyRune, Rune: '-'},
+	"\x1bOn": {Type: KeyRune, Rune: '.'},
+	"\x1bOo": {Type: KeyRune, Rune: '/'},
+	"\x1bOp": {Type: KeyRune, Rune: '0'},
+	"\x1bOq": {Type: KeyRune, Rune: '1'},
+	"\x1bOr": {Type: KeyRune, Rune: '2'},
+	"\x1bOs": {Type: KeyRune, Rune: '3'},
+	"\x1bOt": {Type: KeyRune, Rune: '4'},
+	"\x1bOu": {Type: KeyRune, Rune: '5'},
+	"\x1bOv": {Type: KeyRune, Rune: '6'},
+	"\x1bOw": {Type: KeyRune, Rune: '7'},
+	"\x1bOx": {Type: KeyRune, Rune: '8'},
+	"\x1bOy": {Type: KeyRune, Rune: '9'},
+	"\x1bOX": {Type: KeyRune, Rune: '='},
+}
 
-	// Read and block
-	numBytes, err := r.Read(buf[:])
-	if err != nil {
-		return nil, err
-	}
+// parseInput parses a chunk of keypress and mouse input read from a TTY and
+// returns a message containing information about the key or mouse event
+// accordingly.
+func parseInput(buf []byte) (Msg, error) {
+	numBytes := len(buf)
 
 	// See if it's a mouse event. For now we're parsing X10-type mouse events
 	// only.
@@ -294,6 +358,24 @@ func readInput(r io.Reader) (Msg, error) {
 		return MouseMsg(mouseEvent), nil
 	}
 
+	// Is it the terminal answering a device attributes query?
+	if da, ok := parseDeviceAttributes(buf); ok {
+		return da, nil
+	}
+
+	// Or answering a query about which modes it's in?
+	if r, rest := parseModeReports(buf); r != nil && len(rest) == 0 {
+		return r, nil
+	}
+
+	// Is it a focus event? We'll only get these if focus reporting is on.
+	switch string(buf[:numBytes]) {
+	case "\x1b[I":
+		return FocusMsg{}, nil
+	case "\x1b[O":
+		return BlurMsg{}, nil
+	}
+
 	hex := fmt.Sprintf("%x", buf[:numBytes])
 
 	// Some of these need special handling
@@ -301,8 +383,13 @@ func readInput(r io.Reader) (Msg, error) {
 		return KeyMsg(k), nil
 	}
 
+	// Is it a key sent in application cursor or keypad mode?
+	if k, ok := ss3Sequences[string(buf[:numBytes])]; ok {
+		return KeyMsg(k), nil
+	}
+
 	// Get unicode value
-	char, _ := utf8.DecodeRune(buf[:])
+	char, _ := utf8.DecodeRune(buf)
 	if char == utf8.RuneError {
 		return nil, errors.New("could not decode rune")
 	}
@@ -0,0 +1,214 @@
+package tea
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// parsedKeys returns every key the input parser produces, along with the
+// input it produces it from.
+func parsedKeys(t *testing.T) map[string]KeyMsg {
+	t.Helper()
+	inputs := []string{"a", "Z", "5", " ", "上", "é", "\x1ba", "\x1b上", "\x1b\x1b"}
+	for b := 0; b <= keyUS; b++ {
+		inputs = append(inputs, string(rune(b)))
+	}
+	inputs = append(inputs, string(rune(keyDEL)))
+	for seq := range sequences {
+		inputs = append(inputs, seq)
+	}
+	for hex := range hexes {
+		var b []byte
+		if _, err := fmt.Sscanf(hex, "%x", &b); err != nil {
+			t.Fatal(err)
+		}
+		inputs = append(inputs, string(b))
+	}
+	for seq := range ss3Sequences {
+		inputs = append(inputs, seq)
+	}
+
+	keys := make(map[string]KeyMsg, len(inputs))
+	for _, in := range inputs {
+		msg, err := parseInput([]byte(in))
+		if err != nil {
+			t.Fatalf("parsing %q: %v", in, err)
+		}
+		k, ok := msg.(KeyMsg)
+		if !ok {
+			t.Fatalf("parsing %q: got %T, want KeyMsg", in, msg)
+		}
+		keys[in] = k
+	}
+	return keys
+}
+
+func TestNewKeyMsgRoundTrip(t *testing.T) {
+	for in, k := range parsedKeys(t) {
+		name := k.String()
+		got, err := NewKeyMsg(name)
+		if err != nil {
+			t.Errorf("%q parses to %q: %v", in, name, err)
+			continue
+		}
+		if got != k {
+			t.Errorf("%q parses to %#v, named %q, which is %#v", in, k, name, got)
+		}
+		if got.String() != name {
+			t.Errorf("%q named %q, renamed %q", in, name, got.String())
+		}
+	}
+}
+
+func TestNewKeyMsgNames(t *testing.T) {
+	for typ, name := range keyNames {
+		if typ == KeyRune {
+			continue
+		}
+		for _, alt := range []bool{false, true} {
+			want := KeyMsg{Type: KeyType(typ), Alt: alt}
+			n := name
+			if alt {
+				n = "alt+" + name
+			}
+			got, err := NewKeyMsg(n)
+			if err != nil {
+				t.Errorf("%q: %v", n, err)
+				continue
+			}
+			if got != want || got.String() != n {
+				t.Errorf("%q: got %#v (%q), want %#v", n, got, got.String(), want)
+			}
+		}
+	}
+
+	for _, bad := range []string{"", "alt+", "ctrl+nope", "ab", "rune"} {
+		if _, err := NewKeyMsg(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+func TestNewMouseMsgRoundTrip(t *testing.T) {
+	for typ := range mouseEventTypes {
+		for _, mods := range [][2]bool{{false, false}, {true, false}, {false, true}, {true, true}} {
+			m := MouseMsg{Type: typ, Alt: mods[0], Ctrl: mods[1]}
+			name := MouseEvent(m).String()
+			got, err := NewMouseMsg(name)
+			if err != nil {
+				t.Errorf("%q: %v", name, err)
+				continue
+			}
+			if got != m {
+				t.Errorf("%q: got %#v, want %#v", name, got, m)
+			}
+		}
+	}
+	if _, err := NewMouseMsg("shift+left"); err == nil {
+		t.Error("expected an error for an unknown mouse event")
+	}
+}
+
+// TestKeypadEncodings checks that keys sent differently once the cursor keys
+// and keypad are in application mode parse the same as in normal mode.
+func TestKeypadEncodings(t *testing.T) {
+	pairs := [][2]string{
+		{"\x1b[A", "\x1bOA"},
+		{"\x1b[B", "\x1bOB"},
+		{"\x1b[C", "\x1bOC"},
+		{"\x1b[D", "\x1bOD"},
+		{"\x1b[H", "\x1bOH"},
+		{"\x1b[F", "\x1bOF"},
+		{"\r", "\x1bOM"},
+		{"*", "\x1bOj"},
+		{"+", "\x1bOk"},
+		{",", "\x1bOl"},
+		{"-", "\x1bOm"},
+		{".", "\x1bOn"},
+		{"/", "\x1bOo"},
+		{"=", "\x1bOX"},
+	}
+	for i := 0; i <= 9; i++ {
+		pairs = append(pairs, [2]string{string(rune('0' + i)), "\x1bO" + string(rune('p'+i))})
+	}
+
+	for _, p := range pairs {
+		normal, err := parseInput([]byte(p[0]))
+		if err != nil {
+			t.Fatalf("parsing %q: %v", p[0], err)
+		}
+		app, err := parseInput([]byte(p[1]))
+		if err != nil {
+			t.Fatalf("parsing %q: %v", p[1], err)
+		}
+		if app != normal {
+			t.Errorf("%q parses to %#v, but %q parses to %#v", p[1], app, p[0], normal)
+		}
+	}
+}
+
+func TestParseModeReports(t *testing.T) {
+	tests := []struct {
+		in   string
+		want modeReportMsg
+		rest string
+	}{
+		{"\x1b[?1;2$y", modeReportMsg{{mode: 1, setting: 2}}, ""},
+		{"\x1b[?1;1$y\x1b[?66;2$y", modeReportMsg{{mode: 1, setting: 1}, {mode: 66, setting: 2}}, ""},
+		{"\x1b[?1;2$y\x1b[A", modeReportMsg{{mode: 1, setting: 2}}, "\x1b[A"},
+		{"\x1b[?1;2$yq", modeReportMsg{{mode: 1, setting: 2}}, "q"},
+		{"\x1b[?1$y", nil, "\x1b[?1$y"},
+		{"\x1b[?1;2y", nil, "\x1b[?1;2y"},
+		{"\x1b[A", nil, "\x1b[A"},
+	}
+	for _, tt := range tests {
+		got, rest := parseModeReports([]byte(tt.in))
+		if !reflect.DeepEqual(got, tt.want) || string(rest) != tt.rest {
+			t.Errorf("%q: got %v, %q, want %v, %q", tt.in, got, rest, tt.want, tt.rest)
+		}
+	}
+}
+
+func TestParseDeviceAttributes(t *testing.T) {
+	tests := []struct {
+		terminal string
+		in       string
+		want     []int
+	}{
+		{"xterm", "\x1b[?64;1;2;6;9;15;16;17;18;21;22;28c", []int{64, 1, 2, 6, 9, 15, 16, 17, 18, 21, 22, 28}},
+		{"kitty", "\x1b[?62;c", []int{62}},
+		{"alacritty", "\x1b[?6c", []int{6}},
+		{"vte", "\x1b[?65;1;9c", []int{65, 1, 9}},
+	}
+	for _, tt := range tests {
+		msg, err := parseInput([]byte(tt.in))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.terminal, err)
+		}
+		da, ok := msg.(DeviceAttributesMsg)
+		if !ok {
+			t.Errorf("%s: got %T, want DeviceAttributesMsg", tt.terminal, msg)
+			continue
+		}
+		if !reflect.DeepEqual(da.Attributes, tt.want) || da.TimedOut {
+			t.Errorf("%s: got %+v, want attributes %v", tt.terminal, da, tt.want)
+		}
+	}
+
+	// Other sequences ending in c aren't taken for device attributes.
+	for _, in := range []string{"\x1b[c", "\x1b[?6;xc", "\x1b[>1;10;0c"} {
+		if msg, _ := parseInput([]byte(in)); msg != nil {
+			if _, ok := msg.(DeviceAttributesMsg); ok {
+				t.Errorf("%q parsed as device attributes", in)
+			}
+		}
+	}
+}
+
+func TestDeviceAttributesHas(t *testing.T) {
+	da := DeviceAttributesMsg{Attributes: []int{64, 1, 22}}
+	if !da.Has(22) || da.Has(4) {
+		t.Errorf("%v: Has(22) = %v, Has(4) = %v", da.Attributes, da.Has(22), da.Has(4))
+	}
+}
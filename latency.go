@@ -0,0 +1,57 @@
+package tea
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Input latency is the time from input being read to the frame that Update
+// drew in response to it being written to the terminal, which is what decides
+// how responsive typing feels. Measuring it means stamping every message from
+// the input, so it's only done in builds with the tealatency build tag, for
+// benchmarking the path from keypress to frame:
+//
+//   go test -tags tealatency -bench InputLatency
+//
+// In other builds inputLatencyStamps is false and the stamping compiles away
+// to nothing. Measurements turn up in ProgramMetrics.
+
+// inputStamp is when input which led to a frame was read.
+type inputStamp struct {
+	frame uint64
+	read  time.Time
+}
+
+// stampInput records that the frame just written is a response to input read
+// at the given time.
+func (r *renderer) stampInput(read time.Time) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.inputStamps = append(r.inputStamps, inputStamp{frame: r.written, read: read})
+}
+
+// recordInputLatency measures how long the frames responding to input took
+// to arrive, now that they've been drawn. The mutex must be held when calling
+// this.
+func (r *renderer) recordInputLatency() {
+	if len(r.inputStamps) == 0 {
+		return
+	}
+	now := time.Now()
+	i := 0
+	for ; i < len(r.inputStamps) && r.inputStamps[i].frame <= r.drawn; i++ {
+		r.metrics.addInputLatency(now.Sub(r.inputStamps[i].read))
+	}
+	r.inputStamps = append(r.inputStamps[:0], r.inputStamps[i:]...)
+}
+
+// addInputLatency records the latency of a frame responding to input.
+func (m *metrics) addInputLatency(d time.Duration) {
+	atomic.AddUint64(&m.inputFrames, 1)
+	atomic.AddUint64(&m.inputNanos, uint64(d))
+	if uint64(d) > atomic.LoadUint64(&m.maxInputNanos) {
+		// Only the renderer records latencies, and it does so with its
+		// mutex held, so there's no race here.
+		atomic.StoreUint64(&m.maxInputNanos, uint64(d))
+	}
+}
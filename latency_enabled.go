@@ -0,0 +1,7 @@
+//go:build tealatency
+// +build tealatency
+
+package tea
+
+// inputLatencyStamps is whether input latency is measured. See latency.go.
+const inputLatencyStamps = true
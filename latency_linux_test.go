@@ -0,0 +1,210 @@
+// +build linux
+
+package tea
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// latencyRig runs a program on a pseudo-terminal, reading its input from the
+// terminal as well as drawing to it, and times how long key presses take to
+// show up on screen.
+type latencyRig struct {
+	t      testing.TB
+	p      *Program
+	master *os.File
+	tty    *os.File
+	errc   chan error
+	storm  chan struct{}
+
+	keys int
+	out  []byte // output read but not yet looked at
+	read []byte
+}
+
+// latencyModel is the model of a latencyRig's program.
+type latencyModel struct {
+	keys, storms int
+}
+
+// startLatencyRig starts a program drawing a view of the given number of
+// lines, with a command storm sending it messages as fast as it can take them
+// if storm is set. It returns once the first frame's been drawn.
+func startLatencyRig(t testing.TB, lines int, storm bool) *latencyRig {
+	t.Helper()
+	r := &latencyRig{
+		t:     t,
+		errc:  make(chan error, 1),
+		storm: make(chan struct{}),
+		read:  make([]byte, 64*1024),
+	}
+	r.master, r.tty = openPTY(t, 80, 24)
+
+	// The key count goes first and last, so that it's seen whichever end of
+	// a long view is cut off.
+	filler := make([]string, lines)
+	view := func(m Model) string {
+		lm := m.(latencyModel)
+		status := fmt.Sprintf("keys %d; storms %d", lm.keys, lm.storms)
+		for i := range filler {
+			filler[i] = fmt.Sprintf("line %d of %d", i+1, lines)
+		}
+		filler[0], filler[len(filler)-1] = status, status
+		return strings.Join(filler, "\n")
+	}
+	r.p = NewProgram(
+		func() (Model, Cmd) { return latencyModel{}, nil },
+		func(msg Msg, m Model) (Model, Cmd) {
+			lm := m.(latencyModel)
+			switch msg.(type) {
+			case KeyMsg:
+				lm.keys++
+			case StormMsg:
+				lm.storms++
+			}
+			return lm, nil
+		},
+		view,
+		WithInput(r.tty),
+		WithOutput(r.tty),
+	)
+	go func() {
+		r.errc <- r.p.Start()
+	}()
+	r.waitFor(0)
+
+	if storm {
+		go func() {
+			for {
+				select {
+				case <-r.storm:
+					return
+				default:
+					r.p.Send(StormMsg{})
+				}
+			}
+		}()
+	}
+	return r
+}
+
+// press presses a key and waits for the frame showing it to be written,
+// returning how long that took.
+func (r *latencyRig) press() time.Duration {
+	r.t.Helper()
+	start := time.Now()
+	if _, err := r.master.Write([]byte{'a'}); err != nil {
+		r.t.Fatal(err)
+	}
+	r.keys++
+	r.waitFor(r.keys)
+	return time.Since(start)
+}
+
+// waitFor reads the program's output until a frame showing the given number
+// of key presses is written.
+func (r *latencyRig) waitFor(keys int) {
+	r.t.Helper()
+	want := []byte(fmt.Sprintf("keys %d;", keys))
+	for {
+		if i := bytes.Index(r.out, want); i >= 0 {
+			r.out = append(r.out[:0], r.out[i+len(want):]...)
+			return
+		}
+		// Anything before the last few bytes is too early to be part of
+		// what we're looking for.
+		if n := len(r.out) - len(want); n > 0 {
+			r.out = append(r.out[:0], r.out[n:]...)
+		}
+		n, err := r.master.Read(r.read)
+		if err != nil {
+			r.t.Fatalf("waiting for %q: %v", want, err)
+		}
+		r.out = append(r.out, r.read[:n]...)
+	}
+}
+
+// close quits the program and closes the terminal.
+func (r *latencyRig) close() {
+	r.t.Helper()
+	close(r.storm)
+	go func() {
+		_, _ = io.Copy(ioutil.Discard, r.master)
+	}()
+	r.p.Send(Quit())
+	select {
+	case err := <-r.errc:
+		if err != nil {
+			r.t.Error(err)
+		}
+	case <-time.After(testTimeout):
+		r.t.Error("program didn't quit")
+	}
+	r.tty.Close()
+	r.master.Close()
+}
+
+// latencyBudget is how long a key press may take, at most, to show on screen
+// in a small program with nothing else going on: up to a frame's wait for the
+// renderer to draw, plus a little time to get there.
+const latencyBudget = defaultFramerate + 10*time.Millisecond
+
+func TestInputLatency(t *testing.T) {
+	r := startLatencyRig(t, 5, false)
+	defer r.close()
+
+	// Judge by the median, so that the odd slow key press on a busy
+	// machine doesn't fail the test.
+	latencies := make([]time.Duration, 25)
+	for i := range latencies {
+		latencies[i] = r.press()
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	if median := latencies[len(latencies)/2]; median > latencyBudget {
+		t.Errorf("median latency %v is over budget of %v", median, latencyBudget)
+	}
+}
+
+func BenchmarkInputLatency(b *testing.B) {
+	benchmarks := []struct {
+		name  string
+		lines int
+		storm bool
+	}{
+		{"small view", 5, false},
+		{"1000 lines", 1000, false},
+		{"command storm", 5, true},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			r := startLatencyRig(b, bm.lines, bm.storm)
+			defer r.close()
+
+			var max time.Duration
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if d := r.press(); d > max {
+					max = d
+				}
+			}
+			b.StopTimer()
+
+			b.ReportMetric(float64(max.Nanoseconds()), "max-ns")
+			if inputLatencyStamps {
+				// From input being read, rather than written to the
+				// terminal, to the frame being written.
+				m := r.p.Metrics()
+				b.ReportMetric(float64(m.AvgInputLatency.Nanoseconds()), "read-ns/op")
+				b.ReportMetric(float64(m.MaxInputLatency.Nanoseconds()), "read-max-ns")
+			}
+		})
+	}
+}
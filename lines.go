@@ -0,0 +1,226 @@
+package tea
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// SplitLines splits s into lines. Both "\n" and "\r\n" end a line, so views
+// and text from other sources can be handled alike. Like strings.Split, a
+// trailing line ending results in a final empty line.
+func SplitLines(s string) []string {
+	lines := strings.Split(s, "\n")
+	for i := range lines[:len(lines)-1] {
+		lines[i] = strings.TrimSuffix(lines[i], "\r")
+	}
+	return lines
+}
+
+// nextLine returns the first line in b and whatever follows it, splitting
+// lines as SplitLines does. last reports whether it's the final line. It's
+// for walking through text line by line without allocating.
+func nextLine(b []byte) (line, rest []byte, last bool) {
+	i := bytes.IndexByte(b, '\n')
+	if i < 0 {
+		return b, nil, true
+	}
+	line = b[:i]
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		line = line[:n-1]
+	}
+	return line, b[i+1:], false
+}
+
+// Indent indents each line of s by n spaces. Unlike simply adding spaces to
+// the start of each line, Indent takes care that styling doesn't bleed into
+// the indentation: if text attributes, such as a background color, are still
+// in effect from the line before, they're reset for the indentation and put
+// back afterwards.
+func Indent(s string, n int) string {
+	if n <= 0 {
+		return s
+	}
+	return indentWith(s, strings.Repeat(" ", n))
+}
+
+// indentWith does the work for Indent, putting indent at the start of each
+// line of s.
+func indentWith(s, indent string) string {
+	var (
+		b      strings.Builder
+		active []byte // SGR sequences in effect since the last reset
+		raw    = []byte(s)
+	)
+	b.Grow(len(s) + len(indent)*(strings.Count(s, "\n")+1))
+
+	for i := 0; ; {
+		if len(active) > 0 {
+			b.WriteString(resetSeq)
+			b.WriteString(indent)
+			b.Write(active)
+		} else {
+			b.WriteString(indent)
+		}
+
+		// Copy the line over, keeping track of the styling in effect.
+		for i < len(raw) && raw[i] != '\n' {
+			if l := ansiSeqLen(raw[i:]); l > 0 {
+				active = trackSGR(active, raw[i:i+l])
+				b.Write(raw[i : i+l])
+				i += l
+				continue
+			}
+			b.WriteByte(raw[i])
+			i++
+		}
+
+		if i == len(raw) {
+			return b.String()
+		}
+		b.WriteByte('\n')
+		i++
+	}
+}
+
+// Wrap wraps s so that no line is wider than width cells, breaking lines
+// between words where it can and within a word only when the word is too long
+// to fit on a line of its own. Escape sequences don't count towards the width
+// and wide runes are never split across lines. Styling carries on across the
+// breaks Wrap makes: it's reset at the end of the line and put back at the
+// start of the next, so it doesn't bleed into anything placed alongside.
+//
+// Spaces at a break are dropped. Line breaks already in s are kept. If width
+// isn't positive s is returned untouched.
+func Wrap(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+
+	var (
+		b      strings.Builder
+		active []byte // SGR sequences in effect since the last reset
+		word   []byte // the word being collected, along with any sequences
+		raw    = []byte(s)
+
+		wordWidth int
+		gap       int // spaces before the word
+		col       int
+	)
+	b.Grow(len(s) + len(s)/width)
+
+	breakLine := func() {
+		if len(active) > 0 {
+			b.WriteString(resetSeq)
+		}
+		b.WriteByte('\n')
+		b.Write(active)
+		col = 0
+	}
+
+	// placeWord writes the word we've collected, on the line below if it
+	// doesn't fit on this one, and splits it if it's too long for any line.
+	placeWord := func() {
+		switch {
+		case col == 0 || col+gap+wordWidth <= width:
+			b.WriteString(strings.Repeat(" ", gap))
+			col += gap
+		case len(word) > 0:
+			breakLine()
+		}
+
+		for i := 0; i < len(word); {
+			if l := ansiSeqLen(word[i:]); l > 0 {
+				active = trackSGR(active, word[i:i+l])
+				b.Write(word[i : i+l])
+				i += l
+				continue
+			}
+			r, size := utf8.DecodeRune(word[i:])
+			w := runewidth.RuneWidth(r)
+			if col > 0 && col+w > width {
+				breakLine()
+			}
+			b.Write(word[i : i+size])
+			col += w
+			i += size
+		}
+
+		word, wordWidth, gap = word[:0], 0, 0
+	}
+
+	for i := 0; i < len(raw); {
+		if l := ansiSeqLen(raw[i:]); l > 0 {
+			word = append(word, raw[i:i+l]...)
+			i += l
+			continue
+		}
+
+		switch raw[i] {
+		case '\n':
+			placeWord()
+			b.WriteByte('\n')
+			col = 0
+			i++
+			continue
+		case ' ':
+			if len(word) > 0 {
+				placeWord()
+			}
+			gap++
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRune(raw[i:])
+		word = append(word, raw[i:i+size]...)
+		wordWidth += runewidth.RuneWidth(r)
+		i += size
+	}
+	placeWord()
+
+	return b.String()
+}
+
+// RepeatString repeats s n times, like strings.Repeat, but takes care that
+// styling left open at the end of s doesn't bleed into the start of the next
+// copy. Text attributes are reset between copies, so each starts out styled
+// as s itself does. It's for drawing styled rules, progress bars and the like.
+// If n isn't positive the result is empty.
+func RepeatString(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+
+	var (
+		active []byte // SGR sequences in effect at the end of s
+		raw    = []byte(s)
+	)
+	for i := 0; i < len(raw); {
+		if l := ansiSeqLen(raw[i:]); l > 0 {
+			active = trackSGR(active, raw[i:i+l])
+			i += l
+			continue
+		}
+		i++
+	}
+	if len(active) == 0 {
+		return strings.Repeat(s, n)
+	}
+	return strings.Repeat(s+resetSeq, n-1) + s
+}
+
+// trackSGR updates active, the SGR sequences currently in effect, with the
+// escape sequence seq and returns it. Resets clear it and other sequences
+// that aren't SGR are ignored.
+func trackSGR(active, seq []byte) []byte {
+	switch {
+	case string(seq) == resetSeq || string(seq) == "\x1b[m":
+		return active[:0]
+	case isSGR(seq):
+		return append(active, seq...)
+	}
+	return active
+}
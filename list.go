@@ -0,0 +1,144 @@
+package tea
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ListNumbering is how the items of a list rendered with ListString are
+// numbered, if at all.
+type ListNumbering int
+
+// Available numberings.
+const (
+	NoNumbering     ListNumbering = iota // bullets: •
+	NumberedArabic                       // 1. 2. 3.
+	NumberedLetters                      // a. b. c. … z. aa. ab.
+	NumberedRoman                        // i. ii. iii.
+)
+
+// ListOption is used to set options when rendering a list with ListString.
+type ListOption func(*listOptions)
+
+// listOptions is how a list is drawn.
+type listOptions struct {
+	bullet    string
+	numbering ListNumbering
+	indent    int
+	spacing   int
+}
+
+// ListBullet sets the bullet put in front of each item of a list that isn't
+// numbered. The default is "•".
+func ListBullet(bullet string) ListOption {
+	return func(o *listOptions) {
+		o.bullet = bullet
+	}
+}
+
+// ListNumbers numbers the items of a list rather than giving them bullets.
+func ListNumbers(n ListNumbering) ListOption {
+	return func(o *listOptions) {
+		o.numbering = n
+	}
+}
+
+// ListIndent sets how many spaces go in front of each item's bullet or
+// number. The default is none.
+func ListIndent(n int) ListOption {
+	return func(o *listOptions) {
+		if n < 0 {
+			n = 0
+		}
+		o.indent = n
+	}
+}
+
+// ListSpacing sets how many blank lines go between items. The default is
+// none.
+func ListSpacing(n int) ListOption {
+	return func(o *listOptions) {
+		if n < 0 {
+			n = 0
+		}
+		o.spacing = n
+	}
+}
+
+// ListString renders items as a list, one after the other, each with a
+// bullet or number in front of it:
+//
+//   tea.ListString([]string{"Eggs", "Milk"}, tea.ListNumbers(tea.NumberedArabic))
+//
+// Numbers are aligned to the right, so that the items line up, and items
+// that take up more than one line have the rest of their lines indented to
+// match their first.
+func ListString(items []string, opts ...ListOption) string {
+	o := listOptions{bullet: "•"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	markers := make([]string, len(items))
+	var width int
+	for i := range items {
+		markers[i] = o.marker(i + 1)
+		if w := StringWidth(markers[i]); w > width {
+			width = w
+		}
+	}
+
+	var (
+		b      strings.Builder
+		indent = strings.Repeat(" ", o.indent)
+		hang   = strings.Repeat(" ", o.indent+width+1)
+	)
+	for i, item := range items {
+		if i > 0 {
+			b.WriteString(strings.Repeat("\n", o.spacing+1))
+		}
+		b.WriteString(indent)
+		b.WriteString(Pad(markers[i], width, AlignRight))
+		b.WriteByte(' ')
+		// The first line's indent is replaced by the marker.
+		b.WriteString(indentWith(item, hang)[len(hang):])
+	}
+	return b.String()
+}
+
+// marker returns what goes in front of the nth item.
+func (o listOptions) marker(n int) string {
+	switch o.numbering {
+	case NumberedArabic:
+		return strconv.Itoa(n) + "."
+	case NumberedLetters:
+		var s []byte
+		for ; n > 0; n = (n - 1) / 26 {
+			s = append([]byte{byte('a' + (n-1)%26)}, s...)
+		}
+		return string(s) + "."
+	case NumberedRoman:
+		if n >= 4000 {
+			// Beyond what Roman numerals can comfortably do.
+			return strconv.Itoa(n) + "."
+		}
+		return roman(n) + "."
+	default:
+		return o.bullet
+	}
+}
+
+// roman returns n, which must be less than 4000, in lowercase Roman numerals.
+func roman(n int) string {
+	var (
+		b       strings.Builder
+		values  = []int{1000, 900, 500, 400, 100, 90, 50, 40, 10, 9, 5, 4, 1}
+		symbols = []string{"m", "cm", "d", "cd", "c", "xc", "l", "xl", "x", "ix", "v", "iv", "i"}
+	)
+	for i, v := range values {
+		for ; n >= v; n -= v {
+			b.WriteString(symbols[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,142 @@
+package tea
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ProgramMetrics contains performance counters for a program. They're handy
+// for displaying in a debug view or exporting to a monitoring system.
+type ProgramMetrics struct {
+	TotalMsgs      uint64 // messages processed by Update
+	DroppedMsgs    uint64 // messages dropped because the queue was full
+	TotalFrames    uint64 // views handed to the renderer
+	TotalRenders   uint64 // frames written to the terminal
+	SkippedRenders uint64 // frames not written because nothing changed
+	DroppedFrames  uint64 // frames replaced by a newer one before they could be written
+
+	DroppedMirrorWrites uint64 // writes not copied to the mirror output because it fell behind
+
+	AvgUpdateLatency  time.Duration // average time spent in Update
+	AvgRenderLatency  time.Duration // average time spent rendering a frame
+	LastRenderLatency time.Duration // time spent rendering the latest frame
+
+	// FramePending is set when a view is waiting to be written to the
+	// terminal. If it's often set and LastRenderLatency is high, the
+	// terminal isn't keeping up, and the program may want to draw less.
+	FramePending bool
+
+	// Input latency: how many frames were drawn in response to input, and
+	// how long it took from reading the input to writing each one out. It's
+	// only measured in builds with the tealatency build tag; otherwise these
+	// are always zero.
+	InputFrames     uint64
+	AvgInputLatency time.Duration
+	MaxInputLatency time.Duration
+}
+
+// metrics holds the raw counters behind ProgramMetrics. All fields are
+// accessed atomically, which on 32-bit platforms requires 64-bit alignment, so
+// a metrics must be the first field of any struct it's embedded in.
+type metrics struct {
+	msgs              uint64
+	droppedMsgs       uint64
+	unreportedDropped uint64 // dropped since the program was last told
+	frames            uint64
+	renders           uint64
+	skippedRenders    uint64
+	droppedFrames     uint64
+	droppedMirror     uint64
+	updateNanos       uint64
+	renderNanos       uint64
+	lastRenderNanos   uint64
+	inputFrames       uint64
+	inputNanos        uint64
+	maxInputNanos     uint64
+	framePending      uint32
+}
+
+// addUpdate records a call to Update which took the given duration.
+func (m *metrics) addUpdate(d time.Duration) {
+	atomic.AddUint64(&m.msgs, 1)
+	atomic.AddUint64(&m.updateNanos, uint64(d))
+}
+
+// addRender records a frame which took the given duration to render.
+func (m *metrics) addRender(d time.Duration) {
+	atomic.AddUint64(&m.renders, 1)
+	atomic.AddUint64(&m.renderNanos, uint64(d))
+	atomic.StoreUint64(&m.lastRenderNanos, uint64(d))
+}
+
+// addFrame records a view handed to the renderer.
+func (m *metrics) addFrame() {
+	atomic.AddUint64(&m.frames, 1)
+}
+
+// setFramePending records whether a view is waiting to be rendered.
+func (m *metrics) setFramePending(pending bool) {
+	var v uint32
+	if pending {
+		v = 1
+	}
+	atomic.StoreUint32(&m.framePending, v)
+}
+
+// addDroppedFrame records a frame which was replaced before it was rendered.
+func (m *metrics) addDroppedFrame() {
+	atomic.AddUint64(&m.droppedFrames, 1)
+}
+
+// addDroppedMirrorWrite records a write which wasn't copied to the mirror
+// output.
+func (m *metrics) addDroppedMirrorWrite() {
+	atomic.AddUint64(&m.droppedMirror, 1)
+}
+
+// addSkippedRender records a frame which was not rendered.
+func (m *metrics) addSkippedRender() {
+	atomic.AddUint64(&m.skippedRenders, 1)
+}
+
+// addDroppedMsg records a message dropped because the queue was full.
+func (m *metrics) addDroppedMsg() {
+	atomic.AddUint64(&m.droppedMsgs, 1)
+	atomic.AddUint64(&m.unreportedDropped, 1)
+}
+
+// snapshot returns the current state of the counters.
+func (m *metrics) snapshot() ProgramMetrics {
+	pm := ProgramMetrics{
+		TotalMsgs:      atomic.LoadUint64(&m.msgs),
+		DroppedMsgs:    atomic.LoadUint64(&m.droppedMsgs),
+		TotalFrames:    atomic.LoadUint64(&m.frames),
+		TotalRenders:   atomic.LoadUint64(&m.renders),
+		SkippedRenders: atomic.LoadUint64(&m.skippedRenders),
+		DroppedFrames:  atomic.LoadUint64(&m.droppedFrames),
+
+		DroppedMirrorWrites: atomic.LoadUint64(&m.droppedMirror),
+
+		LastRenderLatency: time.Duration(atomic.LoadUint64(&m.lastRenderNanos)),
+		FramePending:      atomic.LoadUint32(&m.framePending) == 1,
+
+		InputFrames:     atomic.LoadUint64(&m.inputFrames),
+		MaxInputLatency: time.Duration(atomic.LoadUint64(&m.maxInputNanos)),
+	}
+	if pm.TotalMsgs > 0 {
+		pm.AvgUpdateLatency = time.Duration(atomic.LoadUint64(&m.updateNanos) / pm.TotalMsgs)
+	}
+	if pm.TotalRenders > 0 {
+		pm.AvgRenderLatency = time.Duration(atomic.LoadUint64(&m.renderNanos) / pm.TotalRenders)
+	}
+	if pm.InputFrames > 0 {
+		pm.AvgInputLatency = time.Duration(atomic.LoadUint64(&m.inputNanos) / pm.InputFrames)
+	}
+	return pm
+}
+
+// Metrics returns the program's performance counters. It's safe to call from
+// any goroutine.
+func (p *Program) Metrics() ProgramMetrics {
+	return p.metrics.snapshot()
+}
@@ -0,0 +1,85 @@
+package tea
+
+import (
+	"io"
+	"sync"
+)
+
+// mirrorQueueDepth is how many writes can be waiting to go out to a mirror
+// before we start dropping them.
+const mirrorQueueDepth = 256
+
+// mirrorWriter writes to the program's output and, in the background, copies
+// everything written to a mirror. A mirror that can't keep up never holds up
+// the output; writes are dropped instead once too many are waiting.
+type mirrorWriter struct {
+	primary io.Writer
+	mirror  io.Writer
+	metrics *metrics
+
+	mtx    sync.Mutex
+	queue  chan []byte
+	closed bool
+	done   chan struct{}
+}
+
+func newMirrorWriter(primary, mirror io.Writer, m *metrics) *mirrorWriter {
+	w := &mirrorWriter{
+		primary: primary,
+		mirror:  mirror,
+		metrics: m,
+		queue:   make(chan []byte, mirrorQueueDepth),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Write writes b to the output and queues a copy of it for the mirror.
+func (w *mirrorWriter) Write(b []byte) (int, error) {
+	n, err := w.primary.Write(b)
+
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	if w.closed || n == 0 {
+		return n, err
+	}
+
+	// b is often reused, so the mirror needs its own copy.
+	select {
+	case w.queue <- append([]byte(nil), b[:n]...):
+	default:
+		w.metrics.addDroppedMirrorWrite()
+	}
+	return n, err
+}
+
+// run writes queued output to the mirror until the queue's closed.
+func (w *mirrorWriter) run() {
+	defer close(w.done)
+	for b := range w.queue {
+		_, _ = w.mirror.Write(b)
+	}
+}
+
+// close stops mirroring, waiting until everything queued so far has been
+// written to the mirror. Anything written afterwards only goes to the output.
+func (w *mirrorWriter) close() {
+	w.mtx.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mtx.Unlock()
+
+	<-w.done
+}
+
+// terminalOutput returns the writer the program's output ultimately goes to,
+// looking past any mirror.
+func (p *Program) terminalOutput() io.Writer {
+	if m, ok := p.output.(*mirrorWriter); ok {
+		return m.primary
+	}
+	return p.output
+}
@@ -0,0 +1,223 @@
+package tea
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	te "github.com/muesli/termenv"
+)
+
+const (
+	altScreenSeq     = te.CSI + te.AltScreenSeq
+	exitAltScreenSeq = te.CSI + te.ExitAltScreenSeq
+	clearScreenSeq   = te.CSI + "2J"
+	cursorHomeSeq    = te.CSI + "H"
+	hideCursorSeq    = te.CSI + te.HideCursorSeq
+	showCursorSeq    = te.CSI + te.ShowCursorSeq
+	enableFocusSeq   = te.CSI + "?1004h"
+	disableFocusSeq  = te.CSI + "?1004l"
+	enablePasteSeq   = te.CSI + "?2004h"
+	disablePasteSeq  = te.CSI + "?2004l"
+
+	// Application cursor keys (DECCKM) and application keypad (DECKPAM)
+	// modes, and their normal counterparts.
+	appCursorKeysSeq    = te.CSI + "?1h"
+	normalCursorKeysSeq = te.CSI + "?1l"
+	appKeypadSeq        = "\x1b="
+	normalKeypadSeq     = "\x1b>"
+
+	// requestKeyModesSeq asks the terminal (with DECRQM) which modes the
+	// cursor keys and keypad are in. The keypad's mode is DECNKM, which is
+	// what DECKPAM sets.
+	requestKeyModesSeq = te.CSI + "?1$p" + te.CSI + "?66$p"
+)
+
+// Modes the terminal reports on in answer to requestKeyModesSeq.
+const (
+	cursorKeysMode = 1
+	keypadMode     = 66
+)
+
+// modeReset is how the terminal reports that a mode is reset, which for the
+// cursor keys and keypad means normal mode.
+const modeReset = 2
+
+// terminalModes describes the modes the terminal is put into while the
+// program is running. Setting them all up, and later tearing them all down,
+// is done in a single write, so the terminal is never left half set up and
+// there's no flicker as it changes modes.
+type terminalModes struct {
+	altScreen   bool
+	clearScreen bool
+	hideCursor  bool
+	reportFocus bool
+	paste       bool
+
+	// The cursor keys and keypad are only put in application mode once the
+	// terminal's reported that they're in normal mode, so that they can be
+	// put back the way they were found. queryKeyModes asks for that report.
+	appCursorKeys bool
+	appKeypad     bool
+	queryKeyModes bool
+}
+
+// enableSeq returns the sequence that puts the terminal into these modes.
+func (m terminalModes) enableSeq() string {
+	var b strings.Builder
+	if m.altScreen {
+		b.WriteString(altScreenSeq)
+		b.WriteString(cursorHomeSeq)
+	}
+	if m.clearScreen {
+		b.WriteString(clearScreenSeq)
+		b.WriteString(cursorHomeSeq)
+	}
+	if m.hideCursor {
+		b.WriteString(hideCursorSeq)
+	}
+	if m.reportFocus {
+		b.WriteString(enableFocusSeq)
+	}
+	if m.paste {
+		b.WriteString(enablePasteSeq)
+	}
+	if m.appCursorKeys {
+		b.WriteString(appCursorKeysSeq)
+	}
+	if m.appKeypad {
+		b.WriteString(appKeypadSeq)
+	}
+	if m.queryKeyModes {
+		b.WriteString(requestKeyModesSeq)
+	}
+	return b.String()
+}
+
+// disableSeq returns the sequence that takes the terminal back out of these
+// modes. Modes are left in the opposite order to the one they were entered.
+func (m terminalModes) disableSeq() string {
+	var b strings.Builder
+	if m.appKeypad {
+		b.WriteString(normalKeypadSeq)
+	}
+	if m.appCursorKeys {
+		b.WriteString(normalCursorKeysSeq)
+	}
+	if m.paste {
+		b.WriteString(disablePasteSeq)
+	}
+	if m.reportFocus {
+		b.WriteString(disableFocusSeq)
+	}
+	if m.hideCursor {
+		b.WriteString(showCursorSeq)
+	}
+	if m.altScreen {
+		b.WriteString(exitAltScreenSeq)
+	}
+	return b.String()
+}
+
+// startupModes returns the modes the terminal is put into when the program
+// starts, and taken out of when it exits.
+func (p *Program) startupModes() terminalModes {
+	return terminalModes{
+		altScreen:   p.startup == StartupAltScreen || p.startup == StartupAltScreenClear,
+		clearScreen: p.startup == StartupClearScreen || p.startup == StartupAltScreenClear,
+		hideCursor:  true,
+		reportFocus: p.reportFocus,
+		paste:       p.bracketedPaste,
+
+		appCursorKeys: p.appCursorKeys,
+		appKeypad:     p.appKeypad,
+		queryKeyModes: p.queriesKeyModes(),
+	}
+}
+
+// queriesKeyModes reports whether the program asks the terminal which modes
+// the cursor keys and keypad are in. The answer comes back as input, so both
+// input and output need to be the terminal.
+func (p *Program) queriesKeyModes() bool {
+	return terminalFile(p.input) != nil && terminalFile(p.terminalOutput()) != nil
+}
+
+// modeReportMsg is an internal message holding the terminal's answers to
+// DECRQM queries. The terminal may answer several queries in one go.
+type modeReportMsg []modeReport
+
+// modeReport is the terminal's answer to a DECRQM query: the mode asked
+// about, and its setting, which is 1 if it's set and 2 if it's reset.
+type modeReport struct {
+	mode    int
+	setting int
+}
+
+// parseModeReports parses one or more DECRQM answers, each of which looks
+// like ESC [ ? 1 ; 2 $ y, from the start of buf. The terminal may answer in the
+// same read as whatever's typed next, so whatever follows the answers is
+// returned too.
+func parseModeReports(buf []byte) (modeReportMsg, []byte) {
+	var reports modeReportMsg
+	for {
+		r, n, ok := parseModeReport(buf)
+		if !ok {
+			return reports, buf
+		}
+		reports = append(reports, r)
+		buf = buf[n:]
+	}
+}
+
+// parseModeReport parses a DECRQM answer from the start of buf, returning it
+// along with its length.
+func parseModeReport(buf []byte) (modeReport, int, bool) {
+	s := string(buf)
+	if !strings.HasPrefix(s, te.CSI+"?") {
+		return modeReport{}, 0, false
+	}
+	end := strings.Index(s, "$y")
+	if end < 0 {
+		return modeReport{}, 0, false
+	}
+	params := strings.Split(s[len(te.CSI+"?"):end], ";")
+	if len(params) != 2 {
+		return modeReport{}, 0, false
+	}
+	mode, err := strconv.Atoi(params[0])
+	if err != nil {
+		return modeReport{}, 0, false
+	}
+	setting, err := strconv.Atoi(params[1])
+	if err != nil {
+		return modeReport{}, 0, false
+	}
+	return modeReport{mode: mode, setting: setting}, end + len("$y"), true
+}
+
+// keyModesReported puts the cursor keys and keypad in application mode if the
+// terminal's reported that they're in normal mode. If they're already in
+// application mode, or the terminal doesn't say, they're left alone, so that
+// either way exit leaves them as they were found. The parser understands keys
+// sent in both modes, so this is only so that what arrives doesn't depend on
+// what ran before us.
+func (p *Program) keyModesReported(reports modeReportMsg) {
+	if !p.queriesKeyModes() || p.terminalReleased() {
+		return
+	}
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	var m terminalModes
+	for _, r := range reports {
+		if r.setting != modeReset {
+			continue
+		}
+		switch {
+		case r.mode == cursorKeysMode && !p.appCursorKeys:
+			p.appCursorKeys, m.appCursorKeys = true, true
+		case r.mode == keypadMode && !p.appKeypad:
+			p.appKeypad, m.appKeypad = true, true
+		}
+	}
+	_, _ = io.WriteString(p.output, m.enableSeq())
+}
@@ -1,14 +1,23 @@
 package tea
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 type MouseMsg MouseEvent
 
 // MouseEvent represents a mouse event, which could be a click, a scroll wheel
 // movement, a cursor movement, or a combination.
+//
+// Coordinates are zero-based: the top-left cell of the terminal is (0,0),
+// even though the terminal itself reports it as (1,1). The mouse protocol
+// can't report positions beyond column or row 222, so events further out are
+// reported there.
 type MouseEvent struct {
-	X    int
-	Y    int
+	X    int // column, starting from 0
+	Y    int // row, starting from 0
 	Type MouseEventType
 	Alt  bool
 	Ctrl bool
@@ -26,6 +35,30 @@ func (m MouseEvent) String() (s string) {
 	return s
 }
 
+// NewMouseMsg returns a MouseMsg from its name, as produced by
+// MouseEvent.String, such as "left" or "ctrl+alt+wheel up". Names don't
+// include coordinates, so the event is at (0,0); set X and Y as needed. This
+// is useful for simulating mouse input, such as in tests.
+func NewMouseMsg(s string) (MouseMsg, error) {
+	var m MouseMsg
+	if strings.HasPrefix(s, "ctrl+") {
+		m.Ctrl = true
+		s = s[len("ctrl+"):]
+	}
+	if strings.HasPrefix(s, "alt+") {
+		m.Alt = true
+		s = s[len("alt+"):]
+	}
+
+	for t, name := range mouseEventTypes {
+		if name == s {
+			m.Type = t
+			return m, nil
+		}
+	}
+	return MouseMsg{}, fmt.Errorf("unknown mouse event %q", s)
+}
+
 // MouseEventType indicates the type of mouse event occurring.
 type MouseEventType int
 
@@ -100,9 +133,23 @@ func parseX10MouseEvent(buf []byte) (m MouseEvent, err error) {
 		m.Ctrl = true
 	}
 
-	// (1,1) is the upper left. We subtract 1 to normalize it to (0,0).
-	m.X = int(buf[4]) - 32 - 1
-	m.Y = int(buf[5]) - 32 - 1
+	m.X = x10Coord(buf[4])
+	m.Y = x10Coord(buf[5])
 
 	return m, nil
 }
+
+// maxX10Coord is the furthest column or row an X10 mouse event can report,
+// counting from zero. It's sent as a byte, offset by 33.
+const maxX10Coord = 255 - 33
+
+// x10Coord decodes a column or row from an X10 mouse event. (1,1) is the
+// upper left, which we normalize to (0,0). Positions further out than X10 can
+// report are sent by some terminals as zero or wrapped around, so anything
+// out of range is clamped to the furthest position it can report.
+func x10Coord(b byte) int {
+	if b < 33 {
+		return maxX10Coord
+	}
+	return int(b) - 33
+}
@@ -0,0 +1,75 @@
+package tea
+
+import "testing"
+
+// x10Event encodes a mouse event as a terminal reports it in X10 mode, with
+// the given button byte and zero-based position.
+func x10Event(button byte, x, y int) []byte {
+	return []byte{'\x1b', '[', 'M', 32 + button, byte(33 + x), byte(33 + y)}
+}
+
+func TestMouseCoordinates(t *testing.T) {
+	for _, pos := range [][2]int{{0, 0}, {1, 0}, {0, 1}, {79, 23}, {100, 200}, {222, 222}} {
+		x, y := pos[0], pos[1]
+		msg, err := parseInput(x10Event(0, x, y))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := MouseMsg{X: x, Y: y, Type: MouseLeft}
+		if msg != want {
+			t.Errorf("click at (%d,%d): got %#v, want %#v", x, y, msg, want)
+		}
+	}
+}
+
+func TestMouseTopLeft(t *testing.T) {
+	// The terminal reports the top-left cell as column 1, row 1.
+	msg, err := parseInput([]byte("\x1b[M !!"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := msg.(MouseMsg); m.X != 0 || m.Y != 0 {
+		t.Errorf("got (%d,%d), want (0,0)", m.X, m.Y)
+	}
+}
+
+func TestMouseClamp(t *testing.T) {
+	// Positions past what X10 can encode arrive as zero or wrapped bytes.
+	for _, b := range []byte{0, 1, 32} {
+		msg, err := parseInput([]byte{'\x1b', '[', 'M', 32, b, b})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m := msg.(MouseMsg); m.X != 222 || m.Y != 222 {
+			t.Errorf("byte %d: got (%d,%d), want (222,222)", b, m.X, m.Y)
+		}
+	}
+}
+
+func TestMouseButtons(t *testing.T) {
+	tests := []struct {
+		button byte
+		want   MouseMsg
+	}{
+		{0, MouseMsg{Type: MouseLeft}},
+		{1, MouseMsg{Type: MouseMiddle}},
+		{2, MouseMsg{Type: MouseRight}},
+		{3, MouseMsg{Type: MouseRelease}},
+		{35, MouseMsg{Type: MouseMotion}},
+		{64, MouseMsg{Type: MouseWheelUp}},
+		{65, MouseMsg{Type: MouseWheelDown}},
+		{8, MouseMsg{Type: MouseLeft, Alt: true}},
+		{16, MouseMsg{Type: MouseLeft, Ctrl: true}},
+		{24 | 2, MouseMsg{Type: MouseRight, Alt: true, Ctrl: true}},
+	}
+	for _, tt := range tests {
+		tt.want.X, tt.want.Y = 5, 7
+		msg, err := parseInput(x10Event(tt.button, 5, 7))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg != tt.want {
+			t.Errorf("button %d: got %#v, want %#v", tt.button, msg, tt.want)
+		}
+	}
+}
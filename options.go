@@ -0,0 +1,492 @@
+package tea
+
+import (
+	"io"
+	"time"
+)
+
+// ProgramOption is used to set options when initializing a Program. Program
+// can accept a variable number of options.
+//
+//   p := NewProgram(init, update, view, WithManualDriver())
+type ProgramOption func(*Program)
+
+// WithManualDriver puts the Program into manual mode, where the caller drives
+// the event loop rather than Bubble Tea. This is useful when embedding a
+// program in an application that has its own event loop, such as a game
+// engine, and wants Update and View to run on its own goroutine.
+//
+// In manual mode Start sets up the terminal and returns immediately. From
+// there, call Tick regularly to process queued messages and render frames.
+// Input is still read, and commands still run, on Bubble Tea's own
+// goroutines.
+//
+//   p := NewProgram(init, update, view, WithManualDriver())
+//   if err := p.Start(); err != nil {
+//       return err
+//   }
+//   for {
+//       done, err := p.Tick()
+//       if done {
+//           return err
+//       }
+//       // ...the rest of your loop
+//   }
+func WithManualDriver() ProgramOption {
+	return func(p *Program) {
+		p.manual = true
+	}
+}
+
+// WithTabWidth sets the distance between tab stops used when rendering. Tabs
+// in the view are expanded to spaces before they're written so that output
+// looks the same regardless of how the terminal's tab stops are set. The
+// default is 8. A width of zero leaves tabs as they are.
+func WithTabWidth(n int) ProgramOption {
+	return func(p *Program) {
+		p.tabWidth = n
+	}
+}
+
+// WithReportFocus enables focus reporting. When enabled, FocusMsg and BlurMsg
+// are sent to Update when the terminal gains and loses focus, provided the
+// terminal supports it.
+func WithReportFocus() ProgramOption {
+	return func(p *Program) {
+		p.reportFocus = true
+	}
+}
+
+// WithCursorParking hides the cursor while the terminal is out of focus and
+// brings it back when focus returns, so a visible cursor isn't left sitting
+// somewhere odd in an inactive window. It only affects programs that have
+// shown the cursor with ShowCursor; an explicit HideCursor always wins. This
+// option implies WithReportFocus.
+func WithCursorParking() ProgramOption {
+	return func(p *Program) {
+		p.reportFocus = true
+		p.parkCursorOnBlur = true
+	}
+}
+
+// WithMaxMsgQueueDepth sets how many messages can be queued up waiting for
+// Update. By default the queue has no buffer at all, so a command sending a
+// message waits until Update is ready to receive it. Under heavy load, such as
+// when streaming lines from a log, a buffer lets commands get on with things
+// while Update catches up. A depth below zero is taken as zero.
+//
+// See WithBackpressureStrategy for what happens when the queue is full.
+func WithMaxMsgQueueDepth(n int) ProgramOption {
+	return func(p *Program) {
+		if n < 0 {
+			n = 0
+		}
+		p.msgQueueDepth = n
+	}
+}
+
+// WithStartupQueueSize sets how many messages sent before the program starts
+// are held on to for delivery once it has. Messages sent beyond that are
+// dropped, with InjectMsg returning ErrStartupQueueFull. The default is 64.
+func WithStartupQueueSize(n int) ProgramOption {
+	return func(p *Program) {
+		p.startupQueueSize = n
+	}
+}
+
+// WithBackpressureStrategy sets what happens to messages from commands when
+// the message queue is full. By default commands wait until there's room
+// (BackpressureBlock). With BackpressureDrop the message is discarded and
+// a DroppedMsgMsg is sent later on. Dropping is best combined with
+// WithMaxMsgQueueDepth, as without a buffer any message that arrives while
+// Update is busy will be dropped.
+//
+// With BackpressureDropOldest, messages that don't fit in the queue wait
+// behind it, and once as many are waiting as the queue holds, the oldest of
+// them is discarded to make room. Either way of dropping gives up the
+// guarantee that every message from a command reaches Update, including
+// those from Quit and Batch, so only opt in if keeping up matters more.
+// Dropped messages are counted in ProgramMetrics.
+func WithBackpressureStrategy(s BackpressureStrategy) ProgramOption {
+	return func(p *Program) {
+		p.backpressure = s
+	}
+}
+
+// WithDropStaleRequests makes the program deliver only the result of the most
+// recent command tagged with Request for any given ID. Results of earlier
+// requests with the same ID are dropped before they reach Update, so there's
+// no need to keep track of which response belongs to which request.
+func WithDropStaleRequests() ProgramOption {
+	return func(p *Program) {
+		p.requests = make(requestTable)
+	}
+}
+
+// WithAccessibleMode runs the program in accessible mode, which is geared
+// towards screen readers and other assistive technology. Rather than
+// repainting the screen in place, each new frame is written out below the
+// last so the output can be followed linearly. TerminalInfoMsg will also
+// report that the user prefers reduced motion.
+//
+// Users can turn on accessible mode for any program by setting the TEA_A11Y
+// environment variable to a true value, such as TEA_A11Y=1.
+func WithAccessibleMode() ProgramOption {
+	return func(p *Program) {
+		p.accessible = true
+	}
+}
+
+// WithAccessibleView sets a View to use in place of the regular one when in
+// accessible mode. Rather than drawing the whole UI, it can return a plain
+// text description of what's changed, which will be printed whenever it
+// differs from the last. This does not turn on accessible mode by itself.
+func WithAccessibleView(view View) ProgramOption {
+	return func(p *Program) {
+		p.accessibleView = view
+	}
+}
+
+// WithCrashReport writes a report to the file at the given path if the program
+// panics, either in Update or in a command. This gives you something useful to
+// ask for when users run into crashes. The level determines how much is
+// included in the report. Crash reports are only written when CatchPanics is
+// set, which it is by default.
+func WithCrashReport(path string, level CrashReportLevel) ProgramOption {
+	return func(p *Program) {
+		p.crashReportPath = path
+		p.crashReportLevel = level
+	}
+}
+
+// WithFrameHistory keeps the last few frames drawn, with the time each was
+// drawn, for working out what was on screen in the lead up to a crash. At most
+// maxFrames frames and maxBytes bytes of them are kept, with older frames
+// forgotten to make room for new ones. Recent frames are included in crash
+// reports, see WithCrashReport, and are available from RecentFrames.
+//
+// Frames aren't kept by default. Keeping them costs a copy of each frame as
+// it's drawn.
+func WithFrameHistory(maxFrames, maxBytes int) ProgramOption {
+	return func(p *Program) {
+		p.frameHistory = maxFrames
+		p.frameHistoryBytes = maxBytes
+	}
+}
+
+// WithCmdPool runs commands on a fixed pool of worker goroutines rather than
+// starting a new goroutine for each one. This can help programs that issue
+// lots of small commands, such as one per keypress. While every worker is
+// busy, further commands wait their turn, so avoid pools that are small
+// relative to the number of long-running commands you have in flight.
+func WithCmdPool(size int) ProgramOption {
+	return func(p *Program) {
+		p.cmdPoolSize = size
+	}
+}
+
+// WithStartupBehavior sets how the screen is prepared before the first frame
+// is rendered: whether the program renders inline below what's already on
+// screen, which is the default, clears the screen first, or takes over the
+// alternate screen.
+func WithStartupBehavior(sb StartupBehavior) ProgramOption {
+	return func(p *Program) {
+		p.startup = sb
+	}
+}
+
+// WithAnsiResetOnClear resets all text attributes before the renderer clears
+// the lines it drew last time. Without it, a background color left open at
+// the end of a frame can bleed into the lines being cleared, leaving colored
+// blank lines behind.
+func WithAnsiResetOnClear() ProgramOption {
+	return func(p *Program) {
+		p.ansiResetOnClear = true
+	}
+}
+
+// WithFrameMessages sends a FrameMsg to Update for every frame, from the
+// moment the program starts. Frame messages can also be turned on and off as
+// needed with EnableFrameMessages and DisableFrameMessages.
+func WithFrameMessages() ProgramOption {
+	return func(p *Program) {
+		p.frameMessages = true
+	}
+}
+
+// WithFrameObserver calls fn with each frame the program renders, just before
+// it's written to the terminal, along with the frame's index, starting from
+// zero. Frames that are skipped because nothing changed aren't included. Use
+// it for things like recording a session or generating screenshots.
+//
+// fn is called from the renderer, which waits for it to return, so hand off
+// anything slow to another goroutine.
+func WithFrameObserver(fn func(frame string, n int)) ProgramOption {
+	return func(p *Program) {
+		p.frameObserver = fn
+	}
+}
+
+// WithStrictRendering refuses to render any frame containing control
+// characters or escape sequences that do more than style text, such as ones
+// that move the cursor, clear the screen or change the window title. These
+// usually get into a view by way of untrusted text, like file contents, and
+// can corrupt the display or worse. The last good frame stays on screen and
+// the problem is reported with the log package; see LogToFile.
+//
+// Only use this if your views don't deliberately contain such sequences. To
+// clean up untrusted text before it reaches the view, use Sanitize.
+func WithStrictRendering() ProgramOption {
+	return func(p *Program) {
+		p.strictRendering = true
+	}
+}
+
+// WithLinePadding pads every line of the view with spaces out to the width of
+// the terminal. Use it with views that set a background color, so that the
+// color reaches the edge of the window on short lines rather than stopping
+// where the text does. The spaces take on whatever colors are in effect at the
+// end of each line. Lines as wide as the terminal or wider aren't padded.
+func WithLinePadding() ProgramOption {
+	return func(p *Program) {
+		p.padLines = true
+	}
+}
+
+// WithSoftWrap wraps lines of the view that are too wide for the terminal
+// between words, as Wrap does, rather than wherever they reach the edge of
+// the terminal. Words too long for a line of their own are still broken up.
+// This lets a view be written as paragraphs and presented at whatever width
+// the terminal happens to be. Views laid out in precise columns should keep
+// their lines within the width instead.
+func WithSoftWrap() ProgramOption {
+	return func(p *Program) {
+		p.softWrap = true
+	}
+}
+
+// WithMaxDroppedFrames limits how many frames in a row can be dropped when the
+// view changes faster than the terminal is redrawn. Normally only the latest
+// view is drawn at each redraw, which is usually what you want, but under
+// sustained load things like progress bars can appear to jump. With a limit
+// of n, at least one of every n+1 frames is drawn. Zero, the default, means
+// no limit. See ProgramMetrics for how many frames are being dropped.
+func WithMaxDroppedFrames(n int) ProgramOption {
+	return func(p *Program) {
+		p.maxDroppedFrames = n
+	}
+}
+
+// WithMaxFrameSize limits how big a view the renderer will draw, in bytes and
+// in lines. Views beyond either limit are cut short, with a marker at the end
+// to show it, and the first frame cut short is reported with the log package;
+// see LogToFile. This keeps a runaway view, such as one built up without bound
+// in a loop, from tying up the terminal and the program's memory. Zero or less
+// means no limit. The defaults, 4MB and 10,000 lines, are far more than any
+// terminal can show.
+func WithMaxFrameSize(maxBytes, maxLines int) ProgramOption {
+	return func(p *Program) {
+		p.maxFrameBytes = maxBytes
+		p.maxFrameLines = maxLines
+	}
+}
+
+// WithOutput sets the output which, by default, is stdout. Output that isn't
+// a terminal, such as a VirtualTerminal, doesn't report its size, so you may
+// want to send a WindowSizeMsg yourself.
+func WithOutput(w io.Writer) ProgramOption {
+	return func(p *Program) {
+		p.output = w
+	}
+}
+
+// WithMirrorOutput sends a copy of everything the program writes to the
+// terminal to w as well, including changes like entering the alternate screen
+// or hiding the cursor, so that w can reproduce what's on screen. This is
+// handy for streaming a program to a browser for a demo, or recording it to
+// a file.
+//
+// Writes to w happen in the background, so a slow mirror won't slow down the
+// program. If w falls too far behind, writes to it are dropped, which
+// ProgramMetrics keeps count of. Everything queued for w is written out
+// before the program exits.
+func WithMirrorOutput(w io.Writer) ProgramOption {
+	return func(p *Program) {
+		p.mirror = w
+	}
+}
+
+// WithInput sets the input which, by default, is stdin. Pass nil to disable
+// input entirely.
+func WithInput(r io.Reader) ProgramOption {
+	return func(p *Program) {
+		p.input = r
+	}
+}
+
+// WithResizePolling detects terminal resizes by checking the size of the
+// terminal at the given interval rather than waiting for the system to say
+// it's changed. A WindowSizeMsg is only sent when the size actually changes.
+//
+// Polling is always used on Windows, where there's no resize signal. Use this
+// option elsewhere if resizes go unnoticed, as can happen when the terminal
+// belongs to another process. An interval of zero or less uses the default
+// of four times a second.
+func WithResizePolling(interval time.Duration) ProgramOption {
+	return func(p *Program) {
+		p.resizePolling = true
+		if interval > 0 {
+			p.resizePollInterval = interval
+		}
+	}
+}
+
+// WithQuitKeys sets keys which quit the program whenever they're pressed.
+// Keys are named as KeyMsg.String names them, such as "ctrl+c" or "q". Quit
+// keys are handled by the program itself and are never passed to Update.
+//
+// By default there are no quit keys, and quitting is entirely up to the
+// program, using the Quit command. Note that while the program is running the
+// terminal is in raw mode, so pressing ctrl+c sends a KeyMsg rather than an
+// interrupt signal.
+//
+// SIGTERM is handled like a quit key, whatever the quit keys are: the program
+// quits, putting the terminal back the way it was, without Update hearing
+// about it. Other signals behave as they would for any other process.
+func WithQuitKeys(keys ...string) ProgramOption {
+	return func(p *Program) {
+		p.quitKeys = make(map[string]struct{}, len(keys))
+		for _, k := range keys {
+			p.quitKeys[k] = struct{}{}
+		}
+	}
+}
+
+// WithBracketedPaste turns on bracketed paste, so that text pasted into the
+// terminal arrives as a single PasteMsg rather than as a keypress for each
+// character. Pasted text can then be told apart from typing, and can't
+// accidentally trigger key bindings.
+func WithBracketedPaste() ProgramOption {
+	return func(p *Program) {
+		p.bracketedPaste = true
+	}
+}
+
+// WithPasteDetection guesses at pastes in terminals without bracketed paste,
+// see WithBracketedPaste. Input with at least threshold printable characters
+// in a single read arrives faster than anyone can type, so it's taken to be
+// pasted and sent as a PasteMsg, along with any more input following hard on
+// its heels. Escape sequences in pasted text, such as in a log excerpt, are
+// left as they are rather than being taken for keypresses. A threshold of 8
+// or so works well; zero or less turns detection off, which is the default.
+//
+// Being a guess, this can misfire: input from a fast typist on a slow
+// connection, for example, can be mistaken for a paste. Bracketed pastes are
+// still recognized with detection on, and take precedence.
+func WithPasteDetection(threshold int) ProgramOption {
+	return func(p *Program) {
+		p.pasteThreshold = threshold
+	}
+}
+
+// WithPasteLimits bounds how much of a bracketed paste is collected and how
+// long to wait for more of it. The size limit applies to detected pastes too,
+// see WithPasteDetection. A paste larger than maxSize bytes is sent as a
+// truncated PasteMsg holding the first maxSize bytes, and the rest of it is
+// ignored. If no more of a paste arrives within timeout, what's been
+// collected is sent as a truncated PasteMsg and input carries on as normal.
+// This keeps the program responsive in terminals that start a paste but
+// never end it. Values of zero or less keep the defaults of 1 MiB and one
+// second.
+func WithPasteLimits(maxSize int, timeout time.Duration) ProgramOption {
+	return func(p *Program) {
+		if maxSize > 0 {
+			p.maxPasteSize = maxSize
+		}
+		if timeout > 0 {
+			p.pasteTimeout = timeout
+		}
+	}
+}
+
+// WithTerminalHandle has the program take turns with other programs using
+// the same terminal. See TerminalHandle.
+func WithTerminalHandle(h *TerminalHandle) ProgramOption {
+	return func(p *Program) {
+		p.terminalHandle = h
+	}
+}
+
+// WithClock has the program get the time from the given clock rather than the
+// system clock. It's mostly for tests; see TestClock.
+func WithClock(c Clock) ProgramOption {
+	return func(p *Program) {
+		p.clock = c
+	}
+}
+
+// WithRenderDebug describes how each frame is painted to w: how many lines of
+// the last frame were cleared, which lines were written and which skipped, how
+// wide each line was and whether it had to be wrapped, and how many bytes
+// went to the terminal. It's for tracking down rendering glitches such as
+// ghosting and flicker; attach the output to a bug report.
+func WithRenderDebug(w io.Writer) ProgramOption {
+	return func(p *Program) {
+		p.renderDebug = w
+	}
+}
+
+// WithReleasedInput sets what happens to input that's read while the
+// terminal is released. See ReleaseTerminal.
+func WithReleasedInput(s ReleasedInputStrategy) ProgramOption {
+	return func(p *Program) {
+		p.releasedInput = s
+	}
+}
+
+// WithGracefulShutdownTimeout limits how long the program can take to shut
+// down, which includes rendering the final frame and restoring the terminal.
+// If shutting down takes longer than d, because writing to the terminal has
+// hung, for instance, the process exits with status 1. Zero, the default,
+// means no limit.
+func WithGracefulShutdownTimeout(d time.Duration) ProgramOption {
+	return func(p *Program) {
+		p.shutdownTimeout = d
+	}
+}
+
+// WithSlowCmdHook calls fn whenever a command takes at least threshold to run,
+// along with where the command came from and how long it took. Use it to log
+// or otherwise keep an eye on commands that take longer than they should.
+// fn is called from the command's goroutine.
+func WithSlowCmdHook(threshold time.Duration, fn func(origin CmdOrigin, elapsed time.Duration)) ProgramOption {
+	return func(p *Program) {
+		p.slowCmdThreshold = threshold
+		p.slowCmdHook = fn
+	}
+}
+
+// WithNilModelKept makes the program carry on with the previous model when
+// Update returns a nil one, rather than failing with an error wrapping
+// ErrNilModel. That error is passed to report instead, if it isn't nil, so
+// that the mistake can be logged somewhere other than the terminal the program
+// is drawing to; report is called from the event loop. The command Update
+// returned is still run. Init returning nil fails regardless, there being no
+// previous model to keep.
+func WithNilModelKept(report func(err error)) ProgramOption {
+	return func(p *Program) {
+		p.keepNilModel = true
+		p.nilModelReport = report
+	}
+}
+
+// WithFrameBuffering sets whether each frame is assembled in memory and then
+// written to the output in a single write, which is the default. This keeps
+// the number of system calls down and prevents the terminal from displaying
+// partially drawn frames. With buffering off, frames are streamed to the
+// output piece by piece as they're rendered.
+func WithFrameBuffering(enabled bool) ProgramOption {
+	return func(p *Program) {
+		p.frameBuffering = enabled
+	}
+}
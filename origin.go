@@ -0,0 +1,53 @@
+package tea
+
+import (
+	"reflect"
+	"time"
+)
+
+// CmdOrigin describes where a command came from, which is useful when
+// tracking down a misbehaving command.
+type CmdOrigin struct {
+	// MsgType is the type of the message whose Update returned the command.
+	// It's nil for the command returned by Init.
+	MsgType reflect.Type
+
+	// Time is when the command was returned.
+	Time time.Time
+}
+
+// String returns a human-readable description of where a command came from.
+func (o CmdOrigin) String() string {
+	if o.MsgType == nil {
+		return "Init at " + o.Time.Format(time.RFC3339Nano)
+	}
+	return "Update(" + o.MsgType.String() + ") at " + o.Time.Format(time.RFC3339Nano)
+}
+
+// origin is the compact form of CmdOrigin that's passed around with every
+// command, so we keep it to a few words.
+type origin struct {
+	msgType reflect.Type
+	nanos   int64
+}
+
+// originOf returns the origin of a command returned in response to the given
+// message, now.
+func originOf(msg Msg) origin {
+	return origin{msgType: reflect.TypeOf(msg), nanos: time.Now().UnixNano()}
+}
+
+// export returns the origin as a CmdOrigin.
+func (o origin) export() CmdOrigin {
+	return CmdOrigin{MsgType: o.msgType, Time: time.Unix(0, o.nanos)}
+}
+
+// dispatch is a command on its way to being run, along with its origin and
+// the number of the frame written to the renderer when it was issued, which
+// is drawn before the command's message is handled. Zero means there's no
+// frame to wait for.
+type dispatch struct {
+	cmd    Cmd
+	origin origin
+	frame  uint64
+}
@@ -0,0 +1,71 @@
+package tea
+
+// queueOverflow sends a message from a command when using
+// BackpressureDropOldest. Messages that don't fit in the message queue wait
+// in line behind it, and once that's full too, the oldest of them is dropped
+// to make room, so commands never have to wait and the most recent messages
+// are the ones that make it to Update.
+//
+// Only messages from commands end up waiting here. Input and the like always
+// go straight to the message queue and are never dropped.
+func (p *Program) queueOverflow(msg Msg) {
+	p.overflowMtx.Lock()
+
+	// Only skip the line if nothing's waiting, or messages would arrive out
+	// of order.
+	if len(p.overflow) == 0 && !p.forwarding {
+		select {
+		case p.msgs <- msg:
+			p.overflowMtx.Unlock()
+			return
+		default:
+		}
+	}
+
+	limit := p.msgQueueDepth
+	if limit < 1 {
+		limit = 1
+	}
+	if len(p.overflow) >= limit {
+		p.overflow = append(p.overflow[:0], p.overflow[1:]...)
+		p.metrics.addDroppedMsg()
+	}
+	p.overflow = append(p.overflow, msg)
+	p.overflowMtx.Unlock()
+
+	select {
+	case p.overflowReady <- struct{}{}:
+	default:
+	}
+}
+
+// forwardOverflow moves messages from the overflow queue to the message queue
+// as room becomes available.
+func (p *Program) forwardOverflow() {
+	for {
+		p.overflowMtx.Lock()
+		if len(p.overflow) == 0 {
+			p.overflowMtx.Unlock()
+			select {
+			case <-p.overflowReady:
+				continue
+			case <-p.done:
+				return
+			}
+		}
+		msg := p.overflow[0]
+		p.overflow = append(p.overflow[:0], p.overflow[1:]...)
+		p.forwarding = true
+		p.overflowMtx.Unlock()
+
+		select {
+		case p.msgs <- msg:
+		case <-p.done:
+			return
+		}
+
+		p.overflowMtx.Lock()
+		p.forwarding = false
+		p.overflowMtx.Unlock()
+	}
+}
@@ -0,0 +1,109 @@
+package tea
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// PlaceOverlay places fg over bg with its top left corner at column x and row
+// y of bg, counting from zero, and returns the result. It's for drawing
+// dialogs, popups and the like on top of a view. The cells fg covers are
+// replaced, and the rest of bg is left as it was, styling included. bg is
+// extended with blank space if fg doesn't fit within it.
+//
+// Escape sequences in either string don't take up any room, and wide runes
+// take up their full width. A wide rune in bg that's only partly covered by fg
+// is replaced with spaces.
+func PlaceOverlay(x, y int, fg, bg string) string {
+	if x < 0 {
+		x = 0
+	}
+	if y < 0 {
+		y = 0
+	}
+
+	bgLines, fgLines := SplitLines(bg), SplitLines(fg)
+	for len(bgLines) < y+len(fgLines) {
+		bgLines = append(bgLines, "")
+	}
+
+	for i, fgLine := range fgLines {
+		bgLine := bgLines[y+i]
+		w := StringWidth(fgLine)
+
+		var b strings.Builder
+		left := sliceCells(bgLine, 0, x)
+		b.WriteString(left)
+		b.WriteString(strings.Repeat(" ", x-StringWidth(left)))
+		if strings.IndexByte(left, escape) >= 0 {
+			b.WriteString(resetSeq)
+		}
+		b.WriteString(fgLine)
+		if right := sliceCells(bgLine, x+w, -1); right != "" {
+			if strings.IndexByte(fgLine, escape) >= 0 {
+				b.WriteString(resetSeq)
+			}
+			b.WriteString(right)
+		}
+		bgLines[y+i] = b.String()
+	}
+
+	return strings.Join(bgLines, "\n")
+}
+
+// sliceCells returns the part of the line s between cells from and to, or to
+// the end of the line if to is negative. Styling in effect at from is put at
+// the start of the result. Wide runes cut in two by either end are replaced
+// with spaces for the part that's inside.
+func sliceCells(s string, from, to int) string {
+	var (
+		b      strings.Builder
+		active []byte // SGR sequences in effect since the last reset
+		raw    = []byte(s)
+		col    int
+		inside bool
+	)
+
+	for i := 0; i < len(raw); {
+		if to >= 0 && col >= to {
+			break
+		}
+
+		if n := ansiSeqLen(raw[i:]); n > 0 {
+			if inside {
+				b.Write(raw[i : i+n])
+			} else {
+				active = trackSGR(active, raw[i:i+n])
+			}
+			i += n
+			continue
+		}
+
+		r, size := utf8.DecodeRune(raw[i:])
+		w := runewidth.RuneWidth(r)
+		start, end := col, col+w
+		col = end
+		i += size
+
+		if end <= from {
+			continue
+		}
+		if !inside {
+			b.Write(active)
+			inside = true
+		}
+
+		switch {
+		case start < from:
+			b.WriteString(strings.Repeat(" ", end-from))
+		case to >= 0 && end > to:
+			b.WriteString(strings.Repeat(" ", to-start))
+		default:
+			b.Write(raw[i-size : i])
+		}
+	}
+
+	return b.String()
+}
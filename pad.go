@@ -0,0 +1,234 @@
+package tea
+
+import (
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Alignment is how Pad places text within the space it's given.
+type Alignment int
+
+// Available alignments. The horizontal and vertical ones are interchangeable,
+// so AlignTop, for instance, is the same as AlignLeft.
+const (
+	AlignLeft Alignment = iota
+	AlignRight
+	AlignCenter
+
+	AlignTop    = AlignLeft
+	AlignBottom = AlignRight
+	AlignMiddle = AlignCenter
+)
+
+// Pad pads s with spaces so that it takes up width cells on screen, placing
+// it to the left, to the right or in the middle. Escape sequences, such as
+// colors, don't count towards the width and wide runes count for their full
+// width, so styled text lines up as it should. When s can't be centered
+// exactly, the extra space goes on the right. s is expected to be a single
+// line; if it's already width cells wide or wider it's returned untouched.
+func Pad(s string, width int, align Alignment) string {
+	gap := width - StringWidth(s)
+	if gap <= 0 {
+		return s
+	}
+
+	switch align {
+	case AlignRight:
+		return strings.Repeat(" ", gap) + s
+	case AlignCenter:
+		left := gap / 2
+		return strings.Repeat(" ", left) + s + strings.Repeat(" ", gap-left)
+	default:
+		return s + strings.Repeat(" ", gap)
+	}
+}
+
+// JoinVertical stacks strs one on top of the other. Every line is padded with
+// Pad to the width of the widest line, so the result is a rectangle with each
+// line aligned as given.
+func JoinVertical(align Alignment, strs ...string) string {
+	var (
+		lines []string
+		width int
+	)
+	for _, s := range strs {
+		for _, l := range SplitLines(s) {
+			if w := StringWidth(l); w > width {
+				width = w
+			}
+			lines = append(lines, l)
+		}
+	}
+
+	for i, l := range lines {
+		lines[i] = Pad(l, width, align)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// JoinHorizontal places strs side by side. Each one is padded out to the width
+// of its widest line, and ones with fewer lines than the tallest are padded
+// with blank lines at the top, bottom or both, as given by align: AlignTop,
+// AlignBottom or AlignMiddle.
+func JoinHorizontal(align Alignment, strs ...string) string {
+	var (
+		blocks = make([][]string, len(strs))
+		widths = make([]int, len(strs))
+		height int
+	)
+	for i, s := range strs {
+		blocks[i] = SplitLines(s)
+		widths[i] = MaxWidth(s)
+		if len(blocks[i]) > height {
+			height = len(blocks[i])
+		}
+	}
+
+	rows := make([]strings.Builder, height)
+	for i, block := range blocks {
+		var top int
+		switch gap := height - len(block); align {
+		case AlignBottom:
+			top = gap
+		case AlignMiddle:
+			top = gap / 2
+		}
+
+		blank := strings.Repeat(" ", widths[i])
+		for r := range rows {
+			if l := r - top; l >= 0 && l < len(block) {
+				rows[r].WriteString(Pad(block[l], widths[i], AlignLeft))
+			} else {
+				rows[r].WriteString(blank)
+			}
+		}
+	}
+
+	lines := make([]string, height)
+	for r := range rows {
+		lines[r] = rows[r].String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Place places s within a box width cells wide and height lines tall, filling
+// the rest of the box with spaces. hAlign places it horizontally, AlignLeft,
+// AlignCenter or AlignRight, and vAlign places it vertically, AlignTop,
+// AlignMiddle or AlignBottom. It's handy for things like centering a dialog
+// on the screen:
+//
+//   tea.Place(m.width, m.height, tea.AlignCenter, tea.AlignMiddle, dialog)
+//
+// s keeps its shape: its lines are padded to the width of the widest before
+// being placed. As with Pad, when s can't be centered exactly the extra space
+// goes on the right or below, and s is never cut down to fit the box.
+func Place(width, height int, hAlign, vAlign Alignment, s string) string {
+	lines := SplitLines(s)
+	w := MaxWidth(s)
+	if width < w {
+		width = w
+	}
+
+	var top, bottom int
+	if gap := height - len(lines); gap > 0 {
+		switch vAlign {
+		case AlignBottom:
+			top = gap
+		case AlignMiddle:
+			top = gap / 2
+		}
+		bottom = gap - top
+	}
+
+	blank := strings.Repeat(" ", width)
+	out := make([]string, 0, top+len(lines)+bottom)
+	for i := 0; i < top; i++ {
+		out = append(out, blank)
+	}
+	for _, l := range lines {
+		out = append(out, Pad(Pad(l, w, AlignLeft), width, hAlign))
+	}
+	for i := 0; i < bottom; i++ {
+		out = append(out, blank)
+	}
+	return strings.Join(out, "\n")
+}
+
+// Margin surrounds s with blank space: top blank lines above it, bottom blank
+// lines below it, and left and right spaces either side of each line. Lines
+// are padded out to the width of the widest so the margin on the right lines
+// up, and the result is a rectangle. As with Indent, styling doesn't bleed
+// into the margin on the left. Negative margins count as zero.
+func Margin(top, right, bottom, left int, s string) string {
+	top, right, bottom, left = clampZero(top), clampZero(right), clampZero(bottom), clampZero(left)
+	s = Indent(s, left)
+	lines := SplitLines(s)
+	width := MaxWidth(s) + right
+
+	blank := strings.Repeat(" ", width)
+	out := make([]string, 0, top+len(lines)+bottom)
+	for i := 0; i < top; i++ {
+		out = append(out, blank)
+	}
+	for _, l := range lines {
+		out = append(out, Pad(l, width, AlignLeft))
+	}
+	for i := 0; i < bottom; i++ {
+		out = append(out, blank)
+	}
+	return strings.Join(out, "\n")
+}
+
+// Padding adds blank space inside a box: top blank lines above s, bottom blank
+// lines below it, and left and right spaces either side of each line. Lines
+// are filled out to the width of the widest so the result is a rectangle,
+// ready to go inside a Border. Use PaddingWith to pad with something other
+// than spaces.
+func Padding(top, right, bottom, left int, s string) string {
+	return PaddingWith(' ', top, right, bottom, left, s)
+}
+
+// PaddingWith is like Padding but fills the padding with the given character
+// rather than spaces. Characters wider than a cell are used as many times as
+// they fit, with spaces making up the rest. As with Indent, styling doesn't
+// bleed into the padding on the left. Negative padding counts as zero.
+func PaddingWith(fill rune, top, right, bottom, left int, s string) string {
+	top, right, bottom = clampZero(top), clampZero(right), clampZero(bottom)
+	if left > 0 {
+		s = indentWith(s, fillCells(fill, left))
+	}
+	lines := SplitLines(s)
+	width := MaxWidth(s)
+
+	out := make([]string, 0, top+len(lines)+bottom)
+	blank := fillCells(fill, width+right)
+	for i := 0; i < top; i++ {
+		out = append(out, blank)
+	}
+	for _, l := range lines {
+		out = append(out, l+fillCells(fill, width-StringWidth(l)+right))
+	}
+	for i := 0; i < bottom; i++ {
+		out = append(out, blank)
+	}
+	return strings.Join(out, "\n")
+}
+
+// clampZero returns n, or zero if n is negative.
+func clampZero(n int) int {
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// fillCells returns n cells' worth of fill, making up any cells a wide fill
+// character can't with spaces.
+func fillCells(fill rune, n int) string {
+	w := runewidth.RuneWidth(fill)
+	if w <= 0 {
+		fill, w = ' ', 1
+	}
+	return strings.Repeat(string(fill), n/w) + strings.Repeat(" ", n%w)
+}
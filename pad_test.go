@@ -0,0 +1,65 @@
+package tea
+
+import "testing"
+
+func TestMargin(t *testing.T) {
+	tests := []struct {
+		name                     string
+		top, right, bottom, left int
+		in, want                 string
+	}{
+		{"none", 0, 0, 0, 0, "ab\nc", "ab\nc "},
+		{"all sides", 1, 2, 1, 1, "ab\nc", "     \n ab  \n c   \n     "},
+		{"negative", -5, -1, -2, -3, "ab\nc", "ab\nc "},
+		{"negative top only", -5, 1, 1, 0, "x", "x \n  "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Margin(tt.top, tt.right, tt.bottom, tt.left, tt.in); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaddingWith(t *testing.T) {
+	tests := []struct {
+		name                     string
+		fill                     rune
+		top, right, bottom, left int
+		in, want                 string
+	}{
+		{"spaces", ' ', 1, 1, 0, 2, "ab\nc", "     \n  ab \n  c  "},
+		{"fill", '.', 0, 1, 1, 1, "ab\nc", ".ab.\n.c..\n...."},
+		{"wide fill", '＊', 0, 3, 0, 0, "a", "a＊ "},
+		{"negative", '.', -5, -1, -2, -3, "ab\nc", "ab\nc."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PaddingWith(tt.fill, tt.top, tt.right, tt.bottom, tt.left, tt.in); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlace(t *testing.T) {
+	tests := []struct {
+		name           string
+		hAlign, vAlign Alignment
+		want           string
+	}{
+		{"top left", AlignLeft, AlignTop, "ab   \nc    \n     \n     "},
+		{"center", AlignCenter, AlignMiddle, "     \n ab  \n c   \n     "},
+		{"bottom right", AlignRight, AlignBottom, "     \n     \n   ab\n   c "},
+		{"top right", AlignRight, AlignTop, "   ab\n   c \n     \n     "},
+		{"bottom left", AlignLeft, AlignBottom, "     \n     \nab   \nc    "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Place(5, 4, tt.hAlign, tt.vAlign, "ab\nc"); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package tea
+
+import (
+	"io"
+	"strings"
+)
+
+// Pane is one of the regions of a split screen, laid out side by side with
+// the others. See WithPanes.
+type Pane struct {
+	// Width is how many cells wide the pane is. Panes with no width share
+	// whatever the others leave, in proportion to their weight. A pane with
+	// no weight counts as having a weight of one.
+	Width  int
+	Weight int
+
+	// View renders the pane, just as a program's View renders the screen.
+	View View
+}
+
+// WithPanes splits the screen into panes placed side by side from left to
+// right, such as the two panes of a file manager. Each pane has a view of
+// its own, which is given the program's model, and the program's View isn't
+// used.
+//
+// Panes are drawn one at a time, so when only one of them changes the others
+// are left alone on screen rather than being drawn again. A pane's view is
+// cut off at the pane's width, and panes with fewer lines than the tallest
+// are filled out with blank ones. Resizing the terminal shares its width out
+// again and redraws all the panes.
+//
+// If a pane's View returns NoRender, the screen is left as it is. In
+// accessible mode, a view set with WithAccessibleView is used in place of
+// the panes.
+func WithPanes(panes ...Pane) ProgramOption {
+	return func(p *Program) {
+		p.panes = panes
+	}
+}
+
+// renderView sends the view to the renderer, split into panes if the screen
+// is split.
+func (p *Program) renderView() {
+	if len(p.panes) == 0 || (p.accessible && p.accessibleView != nil) {
+		p.renderer.write(p.currentView())
+		return
+	}
+
+	views := make([]string, len(p.panes))
+	for i, pane := range p.panes {
+		if views[i] = pane.View(p.model); views[i] == NoRender {
+			return
+		}
+	}
+	p.renderer.writePanes(p.panes, views)
+}
+
+// writePanes puts a frame made up of the views of panes in the buffer, like
+// write does for a whole frame.
+func (r *renderer) writePanes(panes []Pane, views []string) {
+	for i, v := range views {
+		var ok bool
+		if views[i], ok = r.prepare(v); !ok {
+			return
+		}
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	// Lay out the panes, cutting off and filling out their lines so that
+	// each is a rectangle, all of the same height.
+	var (
+		widths = paneWidths(panes, views, r.width)
+		lines  = make([][]string, len(views))
+		height int
+	)
+	for i, v := range views {
+		if v != "" {
+			lines[i] = SplitLines(v)
+		}
+		if len(lines[i]) > height {
+			height = len(lines[i])
+		}
+	}
+	for i := range lines {
+		blank := strings.Repeat(" ", widths[i])
+		for y := range lines[i] {
+			l := sliceCells(lines[i][y], 0, widths[i])
+			if strings.ContainsRune(l, '\x1b') {
+				// Don't let styling bleed into the next pane.
+				l += resetSeq
+			}
+			lines[i][y] = Pad(l, widths[i], AlignLeft)
+		}
+		for len(lines[i]) < height {
+			lines[i] = append(lines[i], blank)
+		}
+	}
+
+	rows := make([]string, height)
+	for y := range rows {
+		var b strings.Builder
+		for i := range lines {
+			b.WriteString(lines[i][y])
+		}
+		rows[y] = b.String()
+	}
+	r.setFrame(strings.Join(rows, "\n"), lines, widths)
+}
+
+// paneWidths shares width out among panes. If width isn't known yet, panes
+// without a width of their own are as wide as their views.
+func paneWidths(panes []Pane, views []string, width int) []int {
+	var (
+		widths = make([]int, len(panes))
+		rest   = width
+		total  int // of the weights
+		last   = -1
+	)
+	for i, pane := range panes {
+		switch {
+		case pane.Width > 0:
+			widths[i] = pane.Width
+			rest -= pane.Width
+		case width <= 0:
+			widths[i] = MaxWidth(views[i])
+		default:
+			total += paneWeight(pane)
+			last = i
+		}
+	}
+	if width <= 0 {
+		return widths
+	}
+
+	if rest > 0 && total > 0 {
+		shared := rest
+		for i, pane := range panes {
+			if pane.Width <= 0 {
+				widths[i] = shared * paneWeight(pane) / total
+				rest -= widths[i]
+			}
+		}
+		// Whatever's left from rounding down goes to the last of them.
+		widths[last] += rest
+	}
+
+	// Panes mustn't go past the edge of the terminal, or they'd wrap.
+	var x int
+	for i := range widths {
+		if x+widths[i] > width {
+			widths[i] = width - x
+		}
+		x += widths[i]
+	}
+	return widths
+}
+
+// paneWeight returns the weight of a pane without a width.
+func paneWeight(pane Pane) int {
+	if pane.Weight <= 0 {
+		return 1
+	}
+	return pane.Weight
+}
+
+// paintPanes draws the panes which have changed since the last frame, leaving
+// the rest alone. It reports false, having written nothing, if the whole
+// frame needs drawing instead, such as when the panes have changed size.
+func (r *renderer) paintPanes(out io.Writer) bool {
+	if r.nextPanes == nil || r.stale || len(r.ignoreLines) > 0 || r.report != nil ||
+		len(r.lastPanes) != len(r.nextPanes) {
+		return false
+	}
+	height := len(r.nextPanes[0])
+	if height == 0 || height != r.linesRendered || len(r.lastPanes[0]) != height {
+		return false
+	}
+	for i, w := range r.nextPaneWidths {
+		if r.lastPaneWidths[i] != w {
+			return false
+		}
+	}
+
+	// The cursor starts at the beginning of the last line, and that's where
+	// we leave it.
+	var (
+		bottom = height - 1
+		row    = bottom
+		x      int
+	)
+	for i, lines := range r.nextPanes {
+		for y, l := range lines {
+			if l == r.lastPanes[i][y] {
+				continue
+			}
+			if y < row {
+				cursorUpBy(out, row-y)
+			} else if y > row {
+				cursorDownBy(out, y-row)
+			}
+			row = y
+			cursorColumn(out, x+1)
+			_, _ = io.WriteString(out, l)
+		}
+		x += r.nextPaneWidths[i]
+	}
+	if row < bottom {
+		cursorDownBy(out, bottom-row)
+	}
+
+	if r.altScreenActive {
+		moveCursor(out, r.linesRendered, 0)
+	} else {
+		cursorBack(out, r.width)
+	}
+	return true
+}
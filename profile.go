@@ -0,0 +1,35 @@
+package tea
+
+import (
+	"io"
+
+	te "github.com/muesli/termenv"
+)
+
+// ColorProfile returns the colors the program's output supports, for picking
+// colors for the view. Unlike termenv.ColorProfile, which looks at the
+// process's stdout, it looks at the output the program was given, so a
+// program drawing to a terminal of its own with WithOutput gets colors even
+// when stdout is a pipe, and a program whose output is redirected doesn't get
+// them just because stdout is a terminal.
+//
+// The profile is worked out by termenv, from the TERM and COLORTERM
+// environment variables, if the output is a terminal. Output that isn't a
+// terminal gets termenv.Ascii, except for a VirtualTerminal, which
+// understands every color. As with termenv.EnvColorProfile, NO_COLOR and
+// CLICOLOR turn colors off and CLICOLOR_FORCE turns them on. The profile is
+// also sent to Update in TerminalInfoMsg.
+func (p *Program) ColorProfile() te.Profile {
+	return outputColorProfile(p.terminalOutput())
+}
+
+// outputColorProfile works out the colors out supports.
+func outputColorProfile(out io.Writer) te.Profile {
+	if _, ok := out.(*VirtualTerminal); ok {
+		if te.NewOutput(out).EnvNoColor() {
+			return te.Ascii
+		}
+		return te.TrueColor
+	}
+	return te.NewOutput(out).Profile
+}
@@ -0,0 +1,133 @@
+// +build linux
+
+package tea
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	te "github.com/muesli/termenv"
+)
+
+// colorEnv is the environment variables that decide the color profile.
+var colorEnv = []string{"TERM", "COLORTERM", "NO_COLOR", "CLICOLOR", "CLICOLOR_FORCE", "CI"}
+
+// setColorEnv sets the environment variables that decide the color profile,
+// unsetting any not in env, and returns a function that puts them back.
+func setColorEnv(env map[string]string) (restore func()) {
+	saved := make(map[string]*string, len(colorEnv))
+	for _, k := range colorEnv {
+		if v, ok := os.LookupEnv(k); ok {
+			saved[k] = &v
+		} else {
+			saved[k] = nil
+		}
+		if v, ok := env[k]; ok {
+			os.Setenv(k, v)
+		} else {
+			os.Unsetenv(k)
+		}
+	}
+	return func() {
+		for k, v := range saved {
+			if v == nil {
+				os.Unsetenv(k)
+			} else {
+				os.Setenv(k, *v)
+			}
+		}
+	}
+}
+
+func TestColorProfile(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want te.Profile
+	}{
+		{map[string]string{"TERM": "xterm-256color", "COLORTERM": "truecolor"}, te.TrueColor},
+		{map[string]string{"TERM": "xterm-256color", "COLORTERM": "24bit"}, te.TrueColor},
+		{map[string]string{"TERM": "xterm-kitty"}, te.TrueColor},
+		{map[string]string{"TERM": "xterm-256color"}, te.ANSI256},
+		{map[string]string{"TERM": "xterm-color"}, te.ANSI},
+		{map[string]string{"TERM": "linux"}, te.ANSI},
+		{map[string]string{"TERM": "dumb"}, te.Ascii},
+		{map[string]string{"TERM": "dumb", "CLICOLOR_FORCE": "1"}, te.ANSI},
+		{map[string]string{"TERM": "xterm-256color", "NO_COLOR": "1"}, te.Ascii},
+		{map[string]string{"TERM": "xterm-256color", "CLICOLOR": "0"}, te.Ascii},
+	}
+
+	pty := openTestPTY(t, 80, 24)
+	defer pty.close()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	for _, tt := range tests {
+		restore := setColorEnv(tt.env)
+		onPTY := NewProgram(nil, nil, nil, WithOutput(pty.tty)).ColorProfile()
+		onPipe := NewProgram(nil, nil, nil, WithOutput(w)).ColorProfile()
+		restore()
+
+		if onPTY != tt.want {
+			t.Errorf("%v: got profile %v on a terminal, want %v", tt.env, onPTY, tt.want)
+		}
+		// Output that isn't a terminal only gets colors if they're
+		// forced.
+		want := te.Ascii
+		if tt.env["CLICOLOR_FORCE"] == "1" {
+			want = te.ANSI
+		}
+		if onPipe != want {
+			t.Errorf("%v: got profile %v on a pipe, want %v", tt.env, onPipe, want)
+		}
+	}
+}
+
+func TestColorProfileOwnTerminal(t *testing.T) {
+	defer setColorEnv(map[string]string{"TERM": "xterm-256color"})()
+
+	// Stdout being a pipe makes no difference to a program drawing to a
+	// terminal of its own.
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	pty := openTestPTY(t, 80, 24)
+	info := make(chan TerminalInfoMsg, 1)
+	p := NewProgram(
+		func() (Model, Cmd) { return 0, nil },
+		func(msg Msg, m Model) (Model, Cmd) {
+			if msg, ok := msg.(TerminalInfoMsg); ok {
+				info <- msg
+				return m, Quit
+			}
+			return m, nil
+		},
+		func(Model) string { return ColorFg("1", "red") },
+		WithInput(nil),
+		WithOutput(pty.tty),
+		WithClock(NewTestClock(time.Now())),
+	)
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	out := pty.close()
+
+	if got := (<-info).ColorProfile; got != te.ANSI256 {
+		t.Errorf("got profile %v, want %v", got, te.ANSI256)
+	}
+	if want := "\x1b[31mred\x1b[0m"; !strings.Contains(out, want) {
+		t.Errorf("got output %q, want it to contain %q", out, want)
+	}
+}
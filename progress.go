@@ -0,0 +1,100 @@
+package tea
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ProgressBarOption is used to set options when rendering a progress bar
+// with ProgressBar.
+type ProgressBarOption func(*progressBarOptions)
+
+// progressBarOptions is how a progress bar is drawn.
+type progressBarOptions struct {
+	filled  rune
+	empty   rune
+	color   string
+	label   string
+	percent bool
+}
+
+// ProgressBarChars sets the characters the filled and empty parts of the bar
+// are drawn with. The defaults are '█' and '░'.
+func ProgressBarChars(filled, empty rune) ProgressBarOption {
+	return func(o *progressBarOptions) {
+		o.filled, o.empty = filled, empty
+	}
+}
+
+// ProgressBarColor colors the filled part of the bar. The color is given as
+// for ColorFg.
+func ProgressBarColor(color string) ProgressBarOption {
+	return func(o *progressBarOptions) {
+		o.color = color
+	}
+}
+
+// ProgressBarLabel puts a label in front of the bar, with a space between
+// them.
+func ProgressBarLabel(label string) ProgressBarOption {
+	return func(o *progressBarOptions) {
+		o.label = label
+	}
+}
+
+// ProgressBarPercentage sets whether the percentage is shown after the bar.
+// It's off by default.
+func ProgressBarPercentage(on bool) ProgressBarOption {
+	return func(o *progressBarOptions) {
+		o.percent = on
+	}
+}
+
+// ProgressBar renders a progress bar width cells wide, filled in to percent,
+// which goes from 0 to 1 and is clamped to that range. The label and
+// percentage, if there are any, are part of the width, leaving the rest for
+// the bar itself. The percentage always takes up the same room, so the bar
+// doesn't change length as it fills:
+//
+//   tea.ProgressBar(0.42, 30, tea.ProgressBarLabel("Copying"), tea.ProgressBarPercentage(true))
+//
+// gives "Copying ███████░░░░░░░░░░  42%".
+func ProgressBar(percent float64, width int, opts ...ProgressBarOption) string {
+	o := progressBarOptions{filled: '█', empty: '░'}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	switch {
+	case math.IsNaN(percent) || percent < 0:
+		percent = 0
+	case percent > 1:
+		percent = 1
+	}
+
+	var b strings.Builder
+	bar := width
+	if o.label != "" {
+		b.WriteString(o.label)
+		b.WriteByte(' ')
+		bar -= StringWidth(o.label) + 1
+	}
+	var suffix string
+	if o.percent {
+		suffix = fmt.Sprintf(" %3.0f%%", percent*100)
+		bar -= len(suffix)
+	}
+
+	if bar > 0 {
+		filled := int(math.Round(percent * float64(bar)))
+		if o.color != "" && filled > 0 {
+			b.WriteString(ColorFg(o.color, fillCells(o.filled, filled)))
+		} else {
+			b.WriteString(fillCells(o.filled, filled))
+		}
+		b.WriteString(fillCells(o.empty, bar-filled))
+	}
+	b.WriteString(suffix)
+	return b.String()
+}
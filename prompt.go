@@ -0,0 +1,323 @@
+package tea
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	te "github.com/muesli/termenv"
+)
+
+// ErrPromptCancelled is returned by Confirm, Select and Input when the user
+// cancels the prompt with ctrl+c or escape.
+var ErrPromptCancelled = errors.New("prompt cancelled")
+
+// promptHandle is shared by the programs prompts run, so that any input read
+// for one after it's finished goes to the next rather than being lost.
+var promptHandle = NewTerminalHandle()
+
+// Confirm asks a yes or no question and reports the answer. The user answers
+// with y or n, and enter means no:
+//
+//   ok, err := tea.Confirm("Overwrite the file?")
+//
+// Confirm, along with Select and Input, is for programs that just need to ask
+// a quick question, without writing a model for it. Each runs a program of
+// its own, so the terminal is set up and put back as usual, and when the
+// question's been answered it's left on screen along with the answer. If
+// stdin or stdout isn't a terminal, the question's asked on a plain line of
+// its own and the answer read from stdin, so that scripts can pipe answers
+// in. io.EOF is returned if stdin runs out before there's an answer.
+//
+// Prompts take over the terminal while they run, so they're not to be used
+// from within another program; from there, use ReadLine.
+func Confirm(prompt string) (bool, error) {
+	if !promptTerminal() {
+		for {
+			line, err := promptLine(prompt + " [y/N] ")
+			if err != nil {
+				return false, err
+			}
+			switch strings.ToLower(strings.TrimSpace(line)) {
+			case "y", "yes":
+				return true, nil
+			case "", "n", "no":
+				return false, nil
+			}
+		}
+	}
+
+	m, err := runPrompt(confirmPrompt{prompt: prompt}, updateConfirm, viewConfirm)
+	if err != nil {
+		return false, err
+	}
+	c := m.(confirmPrompt)
+	if c.cancelled {
+		return false, ErrPromptCancelled
+	}
+	return c.yes, nil
+}
+
+// Select asks the user to pick one of options and returns the index of the
+// one they picked. They move between options with the arrow keys, or j and
+// k, and pick one with enter. See Confirm.
+func Select(prompt string, options []string) (int, error) {
+	if len(options) == 0 {
+		return 0, errors.New("nothing to select from")
+	}
+
+	if !promptTerminal() {
+		var b strings.Builder
+		b.WriteString(prompt)
+		for i, o := range options {
+			fmt.Fprintf(&b, "\n  %d) %s", i+1, o)
+		}
+		b.WriteString("\nEnter a number: ")
+		for {
+			line, err := promptLine(b.String())
+			if err != nil {
+				return 0, err
+			}
+			if n, err := strconv.Atoi(strings.TrimSpace(line)); err == nil && n >= 1 && n <= len(options) {
+				return n - 1, nil
+			}
+		}
+	}
+
+	m, err := runPrompt(selectPrompt{prompt: prompt, options: options}, updateSelect, viewSelect)
+	if err != nil {
+		return 0, err
+	}
+	s := m.(selectPrompt)
+	if s.cancelled {
+		return 0, ErrPromptCancelled
+	}
+	return s.cursor, nil
+}
+
+// Input asks for a line of text and returns it. The user types their answer
+// and presses enter. See Confirm.
+func Input(prompt string) (string, error) {
+	if !promptTerminal() {
+		return promptLine(prompt + " ")
+	}
+
+	m, err := runPrompt(inputPrompt{prompt: prompt}, updateInput, viewInput)
+	if err != nil {
+		return "", err
+	}
+	in := m.(inputPrompt)
+	switch {
+	case in.cancelled:
+		return "", ErrPromptCancelled
+	case in.eof:
+		return "", io.EOF
+	}
+	return string(in.text), nil
+}
+
+// promptTerminal reports whether prompts can be run as programs, which needs
+// both stdin and stdout to be terminals.
+func promptTerminal() bool {
+	return terminalFile(os.Stdin) != nil && terminalFile(os.Stdout) != nil
+}
+
+// promptLine writes prompt to stdout and reads a line from stdin. Input is
+// read a byte at a time so that nothing after the line is taken from stdin.
+func promptLine(prompt string) (string, error) {
+	fmt.Print(prompt)
+	var (
+		line []byte
+		b    = make([]byte, 1)
+	)
+	for {
+		n, err := os.Stdin.Read(b)
+		if n > 0 {
+			if b[0] == '\n' {
+				return strings.TrimSuffix(string(line), "\r"), nil
+			}
+			line = append(line, b[0])
+		}
+		if err == io.EOF && len(line) > 0 {
+			return string(line), nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// runPrompt runs a prompt's model as a program and returns the model it
+// finished with.
+func runPrompt(model Model, update Update, view View) (Model, error) {
+	final := model
+	p := NewProgram(
+		func() (Model, Cmd) {
+			return model, nil
+		},
+		func(msg Msg, m Model) (Model, Cmd) {
+			m, cmd := update(msg, m)
+			final = m
+			return m, cmd
+		},
+		view,
+		WithTerminalHandle(promptHandle),
+	)
+	if err := p.Start(); err != nil {
+		return nil, err
+	}
+	return final, nil
+}
+
+// isPromptCancel reports whether a key cancels a prompt.
+func isPromptCancel(k KeyMsg) bool {
+	return k.Type == KeyCtrlC || k.Type == KeyEsc
+}
+
+// confirmPrompt is the model for Confirm.
+type confirmPrompt struct {
+	prompt    string
+	yes       bool
+	done      bool
+	cancelled bool
+}
+
+// updateConfirm is the Update for Confirm.
+func updateConfirm(msg Msg, m Model) (Model, Cmd) {
+	c := m.(confirmPrompt)
+	k, ok := msg.(KeyMsg)
+	switch {
+	case !ok:
+		return c, nil
+	case isPromptCancel(k):
+		c.cancelled = true
+	case k.String() == "y" || k.String() == "Y":
+		c.yes, c.done = true, true
+	case k.String() == "n" || k.String() == "N" || k.Type == KeyEnter:
+		c.done = true
+	default:
+		return c, nil
+	}
+	return c, Quit
+}
+
+// viewConfirm is the View for Confirm. Once answered, the question's left
+// on screen with the answer.
+func viewConfirm(m Model) string {
+	c := m.(confirmPrompt)
+	switch {
+	case c.cancelled:
+		return c.prompt
+	case !c.done:
+		return c.prompt + " (y/N) "
+	case c.yes:
+		return c.prompt + " Yes"
+	default:
+		return c.prompt + " No"
+	}
+}
+
+// selectPrompt is the model for Select.
+type selectPrompt struct {
+	prompt    string
+	options   []string
+	cursor    int
+	done      bool
+	cancelled bool
+}
+
+// updateSelect is the Update for Select.
+func updateSelect(msg Msg, m Model) (Model, Cmd) {
+	s := m.(selectPrompt)
+	k, ok := msg.(KeyMsg)
+	switch {
+	case !ok:
+	case isPromptCancel(k):
+		s.cancelled = true
+		return s, Quit
+	case k.Type == KeyEnter:
+		s.done = true
+		return s, Quit
+	case k.Type == KeyUp || k.String() == "k":
+		if s.cursor > 0 {
+			s.cursor--
+		}
+	case k.Type == KeyDown || k.String() == "j":
+		if s.cursor < len(s.options)-1 {
+			s.cursor++
+		}
+	}
+	return s, nil
+}
+
+// viewSelect is the View for Select. Once picked, the options are replaced
+// with the one that was picked.
+func viewSelect(m Model) string {
+	s := m.(selectPrompt)
+	switch {
+	case s.cancelled:
+		return s.prompt
+	case s.done:
+		return s.prompt + " " + s.options[s.cursor]
+	}
+
+	var b strings.Builder
+	b.WriteString(s.prompt)
+	for i, o := range s.options {
+		if i == s.cursor {
+			b.WriteString("\n> " + o)
+		} else {
+			b.WriteString("\n  " + o)
+		}
+	}
+	return b.String()
+}
+
+// inputPrompt is the model for Input.
+type inputPrompt struct {
+	prompt    string
+	text      []rune
+	done      bool
+	eof       bool
+	cancelled bool
+}
+
+// updateInput is the Update for Input.
+func updateInput(msg Msg, m Model) (Model, Cmd) {
+	in := m.(inputPrompt)
+	k, ok := msg.(KeyMsg)
+	switch {
+	case !ok:
+	case isPromptCancel(k):
+		in.cancelled = true
+		return in, Quit
+	case k.Type == KeyEnter:
+		in.done = true
+		return in, Quit
+	case k.Type == KeyCtrlD && len(in.text) == 0:
+		in.eof = true
+		return in, Quit
+	case k.Type == KeyBackspace || k.Type == KeyDelete:
+		if len(in.text) > 0 {
+			in.text = in.text[:len(in.text)-1]
+		}
+	case k.Type == KeyCtrlU:
+		in.text = nil
+	case k.Type == KeyRune && !k.Alt:
+		in.text = append(in.text, k.Rune)
+	}
+	return in, nil
+}
+
+// viewInput is the View for Input.
+func viewInput(m Model) string {
+	in := m.(inputPrompt)
+	if in.done || in.eof || in.cancelled {
+		return in.prompt + " " + string(in.text)
+	}
+	// There's no real cursor to show where typing goes, so draw one.
+	return in.prompt + " " + string(in.text) + te.String(" ").Reverse().String()
+}
@@ -0,0 +1,71 @@
+// +build linux
+
+package tea
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// testPTY is a pseudo-terminal for tests. The program under test uses tty,
+// and everything it writes there can be read back from the master side.
+type testPTY struct {
+	master *os.File
+	tty    *os.File
+
+	out  bytes.Buffer
+	done chan struct{}
+}
+
+// openTestPTY opens a pseudo-terminal of the given size and starts collecting
+// what's written to it.
+func openTestPTY(t testing.TB, width, height int) *testPTY {
+	t.Helper()
+	master, tty := openPTY(t, width, height)
+	p := &testPTY{master: master, tty: tty, done: make(chan struct{})}
+	go func() {
+		defer close(p.done)
+		_, _ = io.Copy(&p.out, master)
+	}()
+	return p
+}
+
+// openPTY opens a pseudo-terminal of the given size, returning its master and
+// tty sides.
+func openPTY(t testing.TB, width, height int) (master, tty *os.File) {
+	t.Helper()
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("no pseudo-terminals: %v", err)
+	}
+	fd := int(master.Fd())
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		t.Fatal(err)
+	}
+	n, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tty, err = os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws := &unix.Winsize{Col: uint16(width), Row: uint16(height)}
+	if err := unix.IoctlSetWinsize(int(tty.Fd()), unix.TIOCSWINSZ, ws); err != nil {
+		t.Fatal(err)
+	}
+	return master, tty
+}
+
+// close closes the terminal and returns everything that was written to it.
+func (p *testPTY) close() string {
+	p.tty.Close()
+	<-p.done
+	p.master.Close()
+	return p.out.String()
+}
@@ -0,0 +1,130 @@
+package tea
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// LineMsg is sent with the line read by ReadLine. EOF is set if the user
+// ended input, usually by pressing ctrl+d, rather than pressing enter. Err is
+// set if the terminal couldn't be switched over to read the line.
+type LineMsg struct {
+	Text string
+	EOF  bool
+	Err  error
+}
+
+// ReadLine is a command that reads a single line of text using the terminal's
+// own line editing. The view is put aside, the prompt is printed and the user
+// can type, backspace and so on as they would at a shell prompt. Once they
+// press enter the view is drawn again and the line is sent as a LineMsg.
+//
+// This is an alternative to building a text input into your model for when
+// all you need is a quick answer to a question:
+//
+//     case KeyMsg:
+//         if msg.String() == "r" {
+//             return m, tea.ReadLine("Rename to: ")
+//         }
+//     case LineMsg:
+//         m.name = msg.Text
+//
+// Nothing else is rendered and no input is sent to Update while the line is
+// being read. Keep in mind that the terminal handles keys like ctrl+c itself
+// while reading the line.
+func ReadLine(prompt string) Cmd {
+	return func() Msg {
+		return readLineMsg{prompt: prompt}
+	}
+}
+
+// readLineMsg is an internal message that starts reading a line. You can
+// send a readLineMsg with ReadLine.
+type readLineMsg struct {
+	prompt string
+}
+
+// readLine hands the terminal back in its original, line-buffered mode, reads
+// a line from it and then takes the terminal back. It blocks until the line is
+// read, so it's run as a command.
+func (p *Program) readLine(prompt string) Msg {
+	done := make(chan LineMsg, 1)
+	p.inputMtx.Lock()
+	if p.lineDone != nil {
+		p.inputMtx.Unlock()
+		return LineMsg{Err: errors.New("already reading a line")}
+	}
+	p.lineDone = done
+	p.inputMtx.Unlock()
+
+	if err := p.ReleaseTerminal(); err != nil {
+		p.inputMtx.Lock()
+		p.lineDone = nil
+		p.inputMtx.Unlock()
+		return LineMsg{Err: err}
+	}
+
+	p.mtx.Lock()
+	if !p.altScreenActive {
+		// The cursor's at the start of the last line of the view. Ask on the
+		// line below so the view isn't overwritten.
+		_, _ = io.WriteString(p.output, "\n")
+	}
+	_, _ = io.WriteString(p.output, prompt)
+	p.mtx.Unlock()
+
+	var line LineMsg
+	select {
+	case line = <-done:
+	case <-p.done:
+		return nil
+	}
+	if line.EOF {
+		// The terminal doesn't move on to the next line for us in this case.
+		p.mtx.Lock()
+		_, _ = io.WriteString(p.output, "\n")
+		p.mtx.Unlock()
+	}
+
+	if err := p.RestoreTerminal(); err != nil {
+		line.Err = err
+	}
+	return line
+}
+
+// readingLine reports whether input is being read by ReadLine.
+func (p *Program) readingLine() bool {
+	p.inputMtx.Lock()
+	defer p.inputMtx.Unlock()
+	return p.lineDone != nil
+}
+
+// takeLine hands input to ReadLine, if it's waiting for a line, and reports
+// whether it did. The terminal passes on input a line at a time while
+// ReadLine is waiting, though a long line can arrive in more than one piece.
+// Empty input means the user ended input without pressing enter.
+func (p *Program) takeLine(b []byte) bool {
+	p.inputMtx.Lock()
+	defer p.inputMtx.Unlock()
+
+	if p.lineDone == nil {
+		return false
+	}
+
+	p.line = append(p.line, b...)
+	i := bytes.IndexByte(p.line, '\n')
+	if i < 0 && len(b) > 0 {
+		return true
+	}
+
+	var line LineMsg
+	if i >= 0 {
+		line.Text = string(bytes.TrimSuffix(p.line[:i], []byte("\r")))
+	} else {
+		line.Text, line.EOF = string(p.line), true
+	}
+	p.lineDone <- line
+	p.lineDone, p.line = nil, nil
+	return true
+}
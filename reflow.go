@@ -0,0 +1,84 @@
+package tea
+
+import "fmt"
+
+// ReflowResult is what came of running a program at one terminal size with
+// Reflow.
+type ReflowResult struct {
+	// Size is the terminal size the program was run at.
+	Size WindowSizeMsg
+
+	// Frames are the views drawn, one after Init and one after each update,
+	// with tabs expanded as they would be on screen.
+	Frames []string
+
+	// Err describes the first frame that doesn't fit the terminal, if any.
+	Err error
+}
+
+// Reflow runs a program at each of the given terminal sizes, for testing that
+// its view adapts to them. It's for catching the views that come apart when
+// the terminal is resized, without having to drag a terminal window around:
+//
+//   sizes := []tea.WindowSizeMsg{{Width: 80, Height: 24}, {Width: 20, Height: 5}}
+//   results, err := tea.Reflow(initialize, update, view, script, sizes)
+//   if err != nil {
+//       t.Fatal(err)
+//   }
+//   for _, r := range results {
+//       if r.Err != nil {
+//           t.Errorf("%dx%d: %v", r.Size.Width, r.Size.Height, r.Err)
+//       }
+//   }
+//
+// For each size the program is run with RunHeadless, with a WindowSizeMsg for
+// the size sent before the messages in msgs. The views drawn along the way are
+// collected and checked to fit: a frame fits if it has no more lines than the
+// terminal has rows and none of its lines are wider than the terminal, as
+// StringWidth measures them. Views of NoRender aren't drawn, so they aren't
+// collected.
+//
+// An error is only returned if the program panics, in which case the results
+// for the sizes before it are returned too.
+func Reflow(init Init, update Update, view View, msgs []Msg, sizes []WindowSizeMsg) ([]ReflowResult, error) {
+	results := make([]ReflowResult, 0, len(sizes))
+	for _, size := range sizes {
+		r := ReflowResult{Size: size}
+		script := append([]Msg{size}, msgs...)
+
+		_, err := runHeadless(init, update, script, func(m Model) {
+			frame := view(m)
+			if frame == NoRender {
+				return
+			}
+			frame = ExpandTabs(frame, defaultTabWidth)
+			if r.Err == nil {
+				r.Err = checkFits(frame, len(r.Frames), size)
+			}
+			r.Frames = append(r.Frames, frame)
+		})
+		if err != nil {
+			return results, fmt.Errorf("at %dx%d: %v", size.Width, size.Height, err)
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// checkFits returns an error if frame n doesn't fit a terminal of the given
+// size. Sizes that aren't positive aren't checked.
+func checkFits(frame string, n int, size WindowSizeMsg) error {
+	lines := SplitLines(frame)
+	if size.Height > 0 && len(lines) > size.Height {
+		return fmt.Errorf("frame %d is %d lines tall, more than the %d available", n, len(lines), size.Height)
+	}
+	if size.Width <= 0 {
+		return nil
+	}
+	for i, l := range lines {
+		if w := StringWidth(l); w > size.Width {
+			return fmt.Errorf("line %d of frame %d is %d cells wide, more than the %d available", i, n, w, size.Width)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package tea
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// ReleasedInputStrategy determines what happens to input that's read while
+// the terminal is released. See ReleaseTerminal.
+type ReleasedInputStrategy int
+
+// Available released input strategies.
+const (
+	// ReleasedInputReplay holds on to input read while the terminal is
+	// released and delivers it, in order, once the terminal is restored.
+	// This is the default.
+	ReleasedInputReplay ReleasedInputStrategy = iota
+
+	// ReleasedInputDiscard throws away input read while the terminal is
+	// released.
+	ReleasedInputDiscard
+)
+
+// ReleaseTerminal stops rendering, exits the alternate screen if it's active
+// and puts the terminal back the way it was before the program started, so
+// that something else can use it. This is typically used to run another
+// interactive process, such as an editor. Call RestoreTerminal to take the
+// terminal back.
+//
+// Input isn't read or delivered while the terminal is released. A read from
+// the input that's underway is cancelled before ReleaseTerminal returns,
+// without taking anything from the input, so whatever uses the terminal next
+// gets everything typed from then on. Reads can't be cancelled on macOS or
+// Windows, or when the input isn't a file, so there the first thing typed
+// after the terminal's released can still end up with the program.
+//
+// Input the program has read but not yet delivered when the terminal's
+// released is delivered once it's restored, by default, so nothing is lost;
+// use WithReleasedInput to discard it instead.
+func (p *Program) ReleaseTerminal() error {
+	if atomic.LoadUint32(&p.running) == 0 {
+		return ErrProgramNotRunning
+	}
+
+	p.inputMtx.Lock()
+	p.inputReleased = true
+	cancelled := p.lineDone == nil && p.inputReader != nil && p.inputReader.cancel()
+	p.inputMtx.Unlock()
+	if cancelled {
+		// Make sure the read that was underway is over before handing the
+		// terminal over, so that it can't take input meant for something
+		// else.
+		p.inputReader.wait()
+	}
+
+	p.renderer.release()
+
+	p.mtx.Lock()
+	_, _ = io.WriteString(p.output, p.releaseModes().disableSeq())
+	p.mtx.Unlock()
+
+	return p.restoreTerminal()
+}
+
+// RestoreTerminal takes back the terminal after a call to ReleaseTerminal.
+// The terminal is set up as it was, the alternate screen is re-entered if it
+// was active, and the view is drawn again from scratch.
+func (p *Program) RestoreTerminal() error {
+	if atomic.LoadUint32(&p.running) == 0 {
+		return ErrProgramNotRunning
+	}
+
+	if err := p.initTerminal(); err != nil {
+		return err
+	}
+
+	p.mtx.Lock()
+	_, _ = io.WriteString(p.output, p.releaseModes().enableSeq())
+	p.mtx.Unlock()
+
+	p.renderer.resume()
+	go p.resumeInput()
+	return nil
+}
+
+// releaseModes returns the modes the terminal is taken out of when it's
+// released, and put back into when it's restored.
+func (p *Program) releaseModes() terminalModes {
+	return terminalModes{
+		altScreen:   p.altScreenActive,
+		hideCursor:  true,
+		reportFocus: p.reportFocus,
+		paste:       p.bracketedPaste,
+
+		appCursorKeys: p.appCursorKeys,
+		appKeypad:     p.appKeypad,
+	}
+}
+
+// terminalReleased reports whether the terminal is currently released.
+func (p *Program) terminalReleased() bool {
+	p.inputMtx.Lock()
+	defer p.inputMtx.Unlock()
+	return p.inputReleased
+}
+
+// sendInput sends a message read from the input to the event loop, unless the
+// terminal's been released, in which case it's held on to or discarded.
+func (p *Program) sendInput(msg inputMsg) {
+	if inputLatencyStamps {
+		msg.read = time.Now()
+	}
+	if p.passOnInput(msg) {
+		return
+	}
+
+	p.inputMtx.Lock()
+	if p.inputReleased {
+		if p.releasedInput == ReleasedInputReplay {
+			p.heldInput = append(p.heldInput, msg)
+		}
+		p.inputMtx.Unlock()
+		return
+	}
+	p.inputMtx.Unlock()
+
+	select {
+	case p.msgs <- msg:
+	case <-p.done:
+	}
+}
+
+// passOnInput sends input to the program that owns the terminal now, if it's
+// been handed over to another program through a TerminalHandle, either by
+// yielding it or by finishing. It reports whether it did.
+func (p *Program) passOnInput(msg inputMsg) bool {
+	if p.terminalHandle == nil {
+		return false
+	}
+	select {
+	case <-p.done:
+	default:
+		if !p.terminalReleased() {
+			return false
+		}
+	}
+
+	owner := p.terminalHandle.owner()
+	if owner == nil || owner == p {
+		return false
+	}
+	owner.sendInput(inputMsg{msg: msg.msg, epoch: atomic.LoadUint32(&owner.inputEpoch)})
+	return true
+}
+
+// resumeInput delivers any input held on to while the terminal was released
+// and then lets input flow again. Input read in the meantime waits its turn
+// so that everything arrives in the order it was typed.
+func (p *Program) resumeInput() {
+	p.inputMtx.Lock()
+	defer p.inputMtx.Unlock()
+
+	for _, msg := range p.heldInput {
+		select {
+		case p.msgs <- msg:
+		case <-p.done:
+		}
+	}
+	p.heldInput = nil
+	p.inputReleased = false
+	p.inputCond.Broadcast()
+}
+
+// waitForInput waits until the terminal isn't released before input is read,
+// so that we don't take input meant for whatever's using the terminal. It
+// returns false if the program finishes in the meantime.
+func (p *Program) waitForInput() bool {
+	p.inputMtx.Lock()
+	defer p.inputMtx.Unlock()
+
+	for p.inputReleased && p.lineDone == nil {
+		select {
+		case <-p.done:
+			return false
+		default:
+		}
+		p.inputCond.Wait()
+	}
+	select {
+	case <-p.done:
+		if p.terminalHandle == nil {
+			// Reading's been cancelled for good.
+			return false
+		}
+	default:
+	}
+	p.inputReader.resume()
+	return true
+}
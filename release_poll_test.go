@@ -0,0 +1,99 @@
+// +build dragonfly freebsd linux netbsd openbsd solaris
+
+package tea
+
+import (
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+// readAll reads n bytes from r, failing the test if they don't come.
+func readAll(t *testing.T, r io.Reader, n int) string {
+	t.Helper()
+	got := make(chan string, 1)
+	go func() {
+		b := make([]byte, n)
+		_, _ = io.ReadFull(r, b)
+		got <- string(b)
+	}()
+	select {
+	case s := <-got:
+		return s
+	case <-time.After(testTimeout):
+		t.Fatalf("timed out reading %d bytes", n)
+		return ""
+	}
+}
+
+func TestReleaseTerminalInput(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	defer r.Close()
+
+	tp := startTestProgram(t, WithInput(r))
+	defer tp.stop()
+	key := func(r rune) KeyMsg { return KeyMsg{Type: KeyRune, Rune: r} }
+
+	// Leave the program waiting on a read when the terminal's released.
+	_, _ = w.Write([]byte("a"))
+	tp.expect(key('a'))
+	if err := tp.ReleaseTerminal(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Whatever has the terminal now gets everything typed, with nothing
+	// taken by the program.
+	_, _ = w.Write([]byte("bc"))
+	if got := readAll(t, r, 2); got != "bc" {
+		t.Errorf("released input reads %q, want %q", got, "bc")
+	}
+	tp.expectNone(50 * time.Millisecond)
+
+	// Once the terminal's restored the program carries on reading where
+	// the other left off.
+	_, _ = w.Write([]byte("d"))
+	if err := tp.RestoreTerminal(); err != nil {
+		t.Fatal(err)
+	}
+	tp.expect(key('d'))
+	_, _ = w.Write([]byte("e"))
+	tp.expect(key('e'))
+	tp.expectNone(50 * time.Millisecond)
+}
+
+func TestReleaseTerminalRepeatedly(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	defer r.Close()
+
+	tp := startTestProgram(t, WithInput(r))
+	defer tp.stop()
+
+	// Every key goes to exactly one side, however often the terminal
+	// changes hands.
+	for i := 0; i < 50; i++ {
+		k := rune('a' + i%26)
+		_, _ = w.Write([]byte(string(k)))
+		tp.expect(KeyMsg{Type: KeyRune, Rune: k})
+
+		if err := tp.ReleaseTerminal(); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte("x"))
+		if got := readAll(t, r, 1); got != "x" {
+			t.Fatalf("released input reads %q, want %q", got, "x")
+		}
+		if err := tp.RestoreTerminal(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tp.expectNone(50 * time.Millisecond)
+}
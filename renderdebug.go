@@ -0,0 +1,72 @@
+package tea
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// frameReport records the decisions the renderer makes while painting a
+// frame, for writing out to the debug output given to WithRenderDebug. Its
+// methods do nothing on a nil frameReport, so the renderer can record
+// decisions without checking whether anyone's interested.
+type frameReport struct {
+	num     uint64
+	width   int
+	cleared int // lines of the last frame cleared
+	skipped int // lines skipped because they're ignored
+	written int // lines written
+	wrapped int // lines which took more than one row
+	bytes   int // bytes written to the terminal
+	lines   strings.Builder
+}
+
+// clearLine records that a line of the last frame was cleared.
+func (f *frameReport) clearLine() {
+	if f == nil {
+		return
+	}
+	f.cleared++
+}
+
+// skipLine records that the line at the given row was left alone.
+func (f *frameReport) skipLine(line, row int) {
+	if f == nil {
+		return
+	}
+	f.skipped++
+	fmt.Fprintf(&f.lines, "  line %d (row %d): skipped\n", line, row)
+}
+
+// writeLine records that a line was written at the given row, taking up the
+// given number of rows and width cells.
+func (f *frameReport) writeLine(line, row, rows, width int) {
+	if f == nil {
+		return
+	}
+	f.written++
+	fmt.Fprintf(&f.lines, "  line %d (row %d): written, %d cells", line, row, width)
+	if rows > 1 {
+		f.wrapped++
+		fmt.Fprintf(&f.lines, ", wrapped onto %d rows", rows)
+	}
+	f.lines.WriteByte('\n')
+}
+
+// report writes the report out to w.
+func (f *frameReport) report(w io.Writer) {
+	fmt.Fprintf(w, "frame %d: width %d, %d lines cleared, %d written, %d skipped, %d wrapped, %d bytes\n%s",
+		f.num, f.width, f.cleared, f.written, f.skipped, f.wrapped, f.bytes, f.lines.String())
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n *int
+}
+
+func (c countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	*c.n += n
+	return n, err
+}
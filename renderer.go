@@ -2,16 +2,29 @@ package tea
 
 import (
 	"bytes"
+	"context"
 	"io"
+	"log"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
 )
 
 const (
 	// defaultFramerate specifies the maximum interval at which we should
 	// update the view.
 	defaultFramerate = time.Second / 60
+
+	// defaultMaxFrameBytes and defaultMaxFrameLines are the largest frame
+	// we'll draw without cutting it short.
+	defaultMaxFrameBytes = 4 << 20
+	defaultMaxFrameLines = 10000
+
+	// frameTruncatedMarker ends frames that have been cut short.
+	frameTruncatedMarker = resetSeq + "\n... (view truncated)"
 )
 
 // renderer is a timer-based renderer, updating the view at a given framerate
@@ -23,55 +36,198 @@ type renderer struct {
 	out           io.Writer
 	buf           bytes.Buffer
 	framerate     time.Duration
-	ticker        *time.Ticker
+	ticker        Ticker
 	mtx           *sync.Mutex
 	done          chan struct{}
-	lastRender    string
+	lastRender    []byte
 	linesRendered int
 
+	// whether buf holds a frame that's yet to be rendered, which may be
+	// empty, and whether lastRender has been forgotten, so the next frame
+	// must be drawn in full even if it's the same
+	pending bool
+	stale   bool
+
+	// when the screen's split into panes, the lines of each pane and their
+	// widths, for the frame in buf and the last frame drawn
+	nextPanes      [][]string
+	nextPaneWidths []int
+	lastPanes      [][]string
+	lastPaneWidths []int
+
+	// whether to assemble each frame before writing it out in one go, and
+	// scratch space for doing so, reused from frame to frame to keep garbage
+	// down
+	frameBuffering bool
+	frame          bytes.Buffer
+
 	// essentially whether or not we're using the full size of the terminal
 	altScreenActive bool
 
+	// lines rendered to the main screen before we switched to the alternate
+	// screen
+	mainLinesRendered int
+
 	// renderer dimensions; usually the size of the window
 	width  int
 	height int
 
 	// lines not to render
 	ignoreLines map[int]struct{}
+
+	// distance between tab stops when expanding tabs; zero or less leaves
+	// tabs untouched
+	tabWidth int
+
+	// whether to write frames out one after another instead of repainting
+	// in place
+	accessible bool
+
+	// counters for the program's metrics, and when the input behind frames
+	// yet to be drawn was read, when measuring input latency
+	metrics     *metrics
+	inputStamps []inputStamp
+
+	// whether the program wants the cursor hidden. the cursor is hidden
+	// when the terminal is initialized.
+	cursorHidden bool
+
+	// whether to hide the cursor while the terminal doesn't have focus, and
+	// whether that's currently the case
+	parkCursorOnBlur bool
+	blurred          bool
+
+	// where the time comes from
+	clock Clock
+
+	// whether to pad lines with spaces to the width of the terminal
+	padLines bool
+
+	// whether to wrap long lines between words rather than wherever they
+	// reach the edge of the terminal
+	softWrap bool
+
+	// how many frames in a row may be replaced before they're rendered, with
+	// zero meaning any number, and how many have been so far
+	maxDroppedFrames int
+	framesDropped    int
+
+	// the largest frame to draw, in bytes and lines, before cutting it
+	// short, with zero or less meaning no limit, and whether the last frame
+	// was cut short
+	maxFrameBytes int
+	maxFrameLines int
+	truncated     bool
+
+	// the number of frames written to the buffer and of the last one drawn,
+	// and whether the frame in the buffer has to be drawn before it can be
+	// replaced
+	written uint64
+	drawn   uint64
+	hold    bool
+
+	// closed when the next frame is drawn, if anything's waiting for that
+	drawnCh chan struct{}
+
+	// the last few frames drawn, if we're keeping them
+	history *frameHistory
+
+	// whether to report frames to the program, where to send the reports,
+	// and the number of the next frame
+	frameMsgs bool
+	frames    chan FrameMsg
+	frameNum  uint64
+
+	// called with each frame before it's written, and the number of frames
+	// it's been called with so far
+	observer       func(frame string, n int)
+	framesObserved int
+
+	// whether to refuse frames containing control characters other than
+	// those that style text
+	strict bool
+
+	// whether the terminal has been released to something else, in which
+	// case we hold off drawing
+	released bool
+
+	// whether to reset text attributes before clearing lines, so that
+	// colors left open by the last frame don't bleed into the cleared space
+	resetOnClear bool
+
+	// where to describe how each frame was painted, if anywhere, the report
+	// on the frame being painted, and the number of frames reported
+	debug          io.Writer
+	report         *frameReport
+	framesDebugged uint64
 }
 
 // newRenderer creates a new renderer. Normally you'll want to initialize it
 // with os.Stdout as the first argument.
 func newRenderer(out io.Writer, mtx *sync.Mutex) *renderer {
 	return &renderer{
-		out:       out,
-		mtx:       mtx,
-		framerate: defaultFramerate,
+		out:            out,
+		mtx:            mtx,
+		framerate:      defaultFramerate,
+		tabWidth:       defaultTabWidth,
+		cursorHidden:   true,
+		frameBuffering: true,
+		metrics:        &metrics{},
+		frames:         make(chan FrameMsg, 1),
+		clock:          systemClock{},
 	}
 }
 
 // start starts the renderer.
 func (r *renderer) start() {
 	if r.ticker == nil {
-		r.ticker = time.NewTicker(r.framerate)
+		r.ticker = r.clock.NewTicker(r.framerate)
 	}
 	r.done = make(chan struct{})
 	go r.listen()
 }
 
-// stop permanently halts the renderer.
+// stop permanently halts the renderer, rendering any pending output first.
 func (r *renderer) stop() {
 	r.flush()
-	r.done <- struct{}{}
+
+	// If the renderer was never started there's nothing listening.
+	if r.done != nil {
+		r.done <- struct{}{}
+	}
+
+	r.mtx.Lock()
+	r.leaveFrame()
+	r.mtx.Unlock()
+}
+
+// leaveFrame moves the cursor to the start of the line following the last
+// frame when rendering inline, so that whatever's printed after the program
+// exits, such as the shell prompt, appears below the frame rather than on top
+// of its last line. If the frame ended with a line break, the cursor is
+// already there; otherwise exactly one line break is written. The mutex must
+// be held when calling this.
+func (r *renderer) leaveFrame() {
+	if r.altScreenActive || r.accessible || r.released || len(r.lastRender) == 0 {
+		return
+	}
+	last := r.lastRender
+	if i := bytes.LastIndexByte(last, '\n'); i >= 0 {
+		last = last[i+1:]
+	}
+	if printableWidth(last) > 0 {
+		_, _ = io.WriteString(r.out, "\r\n")
+	}
 }
 
 // listen waits for ticks on the ticker, or a signal to stop the renderer.
 func (r *renderer) listen() {
 	for {
 		select {
-		case <-r.ticker.C:
+		case <-r.ticker.C():
 			if r.ticker != nil {
 				r.flush()
+				r.reportFrame()
 			}
 		case <-r.done:
 			r.mtx.Lock()
@@ -86,40 +242,92 @@ func (r *renderer) listen() {
 
 // flush renders the buffer.
 func (r *renderer) flush() {
-	if r.buf.Len() == 0 || r.buf.String() == r.lastRender {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.render()
+}
+
+// render renders the buffer. The mutex must be held when calling this.
+func (r *renderer) render() {
+	if !r.pending || r.released {
 		// Nothing to do
 		return
 	}
+	if !r.stale && bytes.Equal(r.buf.Bytes(), r.lastRender) {
+		// Nothing's changed since the last render
+		r.metrics.addSkippedRender()
+		r.buf.Reset()
+		r.pending = false
+		r.lastPanes, r.lastPaneWidths = r.nextPanes, r.nextPaneWidths
+		r.framesDropped = 0
+		r.markDrawn()
+		return
+	}
+	start := time.Now()
 
-	// We have an opportunity here to limit the rendering to the terminal width
-	// and height, but this would mean a few things:
-	//
-	// 1) We'd need to maintain the terminal dimensions internally and listen
-	// for window size changes. [done]
-	//
-	// 2) We'd need to measure the width of lines, accounting for multi-cell
-	// rune widths, commonly found in Chinese, Japanese, Korean, emojis and so
-	// on. We'd use something like go-runewidth
-	// (http://github.com/mattn/go-runewidth).
-	//
-	// 3) We'd need to measure the width of lines excluding ANSI escape
-	// sequences and break lines in the right places accordingly.
-	//
-	// Because of the way this would complicate the renderer, this may not be
-	// the place to do that.
-
-	out := new(bytes.Buffer)
+	if r.observer != nil {
+		r.observer(r.buf.String(), r.framesObserved)
+		r.framesObserved++
+	}
 
-	r.mtx.Lock()
-	defer r.mtx.Unlock()
+	// Assemble the whole frame before writing it, if we're buffering. This
+	// keeps the number of writes down and means the terminal never sees
+	// a partial frame.
+	out := r.out
+	if r.frameBuffering {
+		r.frame.Reset()
+		out = &r.frame
+	}
+
+	if r.debug != nil {
+		r.report = &frameReport{num: r.framesDebugged, width: r.width}
+		r.framesDebugged++
+		out = countingWriter{w: out, n: &r.report.bytes}
+	}
+
+	switch {
+	case r.accessible:
+		r.appendFrame(out)
+	case r.paintPanes(out):
+	default:
+		r.paint(out)
+	}
+
+	if r.report != nil {
+		r.report.report(r.debug)
+		r.report = nil
+	}
+
+	if r.frameBuffering {
+		_, _ = r.out.Write(r.frame.Bytes())
+	}
+	if r.history != nil {
+		r.history.add(r.clock.Now(), r.buf.Bytes())
+	}
+	r.lastRender = append(r.lastRender[:0], r.buf.Bytes()...)
+	r.lastPanes, r.lastPaneWidths = r.nextPanes, r.nextPaneWidths
+	r.stale = false
+	r.buf.Reset()
+	r.pending = false
+	r.framesDropped = 0
+	r.markDrawn()
+	r.metrics.addRender(time.Since(start))
+}
 
+// paint writes the sequences needed to replace the last frame with the one in
+// the buffer, in place, to out.
+func (r *renderer) paint(out io.Writer) {
 	// Clear any lines we painted in the last render.
 	if r.linesRendered > 0 {
+		if r.resetOnClear {
+			reset(out)
+		}
 		for i := r.linesRendered - 1; i > 0; i-- {
 			// Check if we should skip rendering for this line. Clearing the
 			// line before painting is part of the standard rendering routine.
 			if _, exists := r.ignoreLines[i]; !exists {
 				clearLine(out)
+				r.report.clearLine()
 			}
 
 			cursorUp(out)
@@ -137,23 +345,37 @@ func (r *renderer) flush() {
 			// we could use that above to eliminate this step.
 			cursorBack(out, r.width)
 			clearLine(out)
+			r.report.clearLine()
 		}
 	}
 
 	r.linesRendered = 0
-	lines := strings.Split(r.buf.String(), "\n")
 
-	// Paint new lines
-	for i := 0; i < len(lines); i++ {
+	// An empty view takes up no lines at all. The cursor's already at the
+	// start of where the frame goes, ready for the next one.
+	if r.buf.Len() == 0 {
+		return
+	}
+
+	// Paint new lines. We walk the buffer directly rather than splitting it
+	// up to avoid allocating for every line of every frame.
+	for rest, last, n := r.buf.Bytes(), false, 0; !last; n, r.linesRendered = n+1, r.linesRendered+1 {
+		var line []byte
+		line, rest, last = nextLine(rest)
+
 		if _, exists := r.ignoreLines[r.linesRendered]; exists {
 			cursorDown(out) // skip rendering for this line.
+			r.report.skipLine(n, r.linesRendered)
 		} else {
-			_, _ = io.WriteString(out, lines[i])
-			if i != len(lines)-1 {
+			row := r.linesRendered
+			r.linesRendered += r.writeLine(out, line)
+			if r.report != nil {
+				r.report.writeLine(n, row, r.linesRendered-row+1, printableWidth(line))
+			}
+			if !last {
 				_, _ = io.WriteString(out, "\r\n")
 			}
 		}
-		r.linesRendered++
 	}
 
 	// Make sure the cursor is at the start of the last line to keep rendering
@@ -166,19 +388,275 @@ func (r *renderer) flush() {
 	} else {
 		cursorBack(out, r.width)
 	}
+}
 
-	_, _ = r.out.Write(out.Bytes())
-	r.lastRender = r.buf.String()
-	r.buf.Reset()
+// writeLine paints a single line of the frame. Lines wider than the terminal
+// are wrapped here rather than left for the terminal to wrap, so that we know
+// exactly how many rows they take up. It returns the number of extra rows the
+// line needed.
+//
+// A wide rune is never started in the last column: terminals disagree on what
+// to do with one there, so instead the rest of the row is filled with spaces
+// and the rune is moved to the next row.
+func (r *renderer) writeLine(out io.Writer, line []byte) int {
+	w := printableWidth(line)
+	if r.width <= 0 || w <= r.width {
+		_, _ = out.Write(line)
+		if r.padLines {
+			writeSpaces(out, r.width-w)
+		}
+		return 0
+	}
+	if r.softWrap {
+		return r.writeSoftWrapped(out, line)
+	}
+
+	var rows, col, start int
+	for i := 0; i < len(line); {
+		if n := ansiSeqLen(line[i:]); n > 0 {
+			i += n
+			continue
+		}
+		c, size := utf8.DecodeRune(line[i:])
+		rw := runewidth.RuneWidth(c)
+		if col > 0 && col+rw > r.width {
+			_, _ = out.Write(line[start:i])
+			writeSpaces(out, r.width-col)
+			_, _ = io.WriteString(out, "\r\n")
+			start = i
+			col = 0
+			rows++
+		}
+		col += rw
+		i += size
+	}
+	_, _ = out.Write(line[start:])
+	if r.padLines {
+		writeSpaces(out, r.width-col)
+	}
+	return rows
+}
+
+// writeSoftWrapped paints a line too wide for the terminal, broken up between
+// words by Wrap. It returns the number of extra rows the line needed.
+func (r *renderer) writeSoftWrapped(out io.Writer, line []byte) int {
+	rows := -1
+	for rest, last := []byte(Wrap(string(line), r.width)), false; !last; rows++ {
+		var l []byte
+		l, rest, last = nextLine(rest)
+		_, _ = out.Write(l)
+		if r.padLines {
+			writeSpaces(out, r.width-printableWidth(l))
+		}
+		if !last {
+			_, _ = io.WriteString(out, "\r\n")
+		}
+	}
+	return rows
+}
+
+// writeSpaces writes n spaces to out.
+func writeSpaces(out io.Writer, n int) {
+	for n > 0 {
+		chunk := n
+		if chunk > len(spaces) {
+			chunk = len(spaces)
+		}
+		_, _ = io.WriteString(out, spaces[:chunk])
+		n -= chunk
+	}
+}
+
+// appendFrame writes the frame in the buffer to out below the last one, rather
+// than painting over it. This is how we render in accessible mode, where
+// output needs to make sense when read linearly, by a screen reader for
+// example.
+func (r *renderer) appendFrame(out io.Writer) {
+	if r.buf.Len() == 0 {
+		return
+	}
+	for rest, last, n := r.buf.Bytes(), false, 0; !last; n++ {
+		var line []byte
+		line, rest, last = nextLine(rest)
+		_, _ = out.Write(line)
+		_, _ = io.WriteString(out, "\r\n")
+		if r.report != nil {
+			r.report.writeLine(n, n, 1, printableWidth(line))
+		}
+	}
 }
 
 // write writes to the internal buffer. The buffer will be outputted via the
-// ticker which calls flush().
+// ticker which calls flush(). If s is NoRender the buffer is left alone.
+//
+// Tabs are expanded to spaces here so that what ends up on screen doesn't
+// depend on the terminal's tab stops.
 func (r *renderer) write(s string) {
+	if s == NoRender {
+		return
+	}
+	s, ok := r.prepare(s)
+	if !ok {
+		return
+	}
+
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
+	r.setFrame(s, nil, nil)
+}
+
+// prepare gets a view ready to be drawn, reporting false if it mustn't be.
+func (r *renderer) prepare(s string) (string, bool) {
+	// Cut runaway views down to size before doing anything else with them.
+	// Only the first of a run of oversized views is logged so as not to
+	// flood the log.
+	t, truncated := truncateFrame(s, r.maxFrameBytes, r.maxFrameLines)
+	if truncated && !r.truncated {
+		log.Printf("bubbletea: truncated %d byte view to %d bytes", len(s), len(t))
+	}
+	s, r.truncated = t, truncated
+
+	if r.strict {
+		if i := findUnsafeControl([]byte(s)); i >= 0 {
+			// Keep showing the last frame rather than let the terminal
+			// be taken over.
+			log.Printf("bubbletea: dropped frame with unsafe control characters at byte %d: %q", i, s[i:min(len(s), i+16)])
+			return "", false
+		}
+	}
+	return ExpandTabs(s, r.tabWidth), true
+}
+
+// setFrame puts a frame in the buffer, ready to be drawn, along with the
+// panes it's made up of, if it's split into panes. The mutex must be held
+// when calling this.
+func (r *renderer) setFrame(s string, panes [][]string, widths []int) {
+	// A command issued alongside the frame in the buffer has finished, so
+	// draw the frame now rather than let the command's results replace it
+	// unseen.
+	if r.hold {
+		r.render()
+	}
+
+	r.metrics.addFrame()
+	if r.pending && (r.stale || !bytes.Equal(r.buf.Bytes(), r.lastRender)) {
+		// The frame we're replacing never made it to the terminal.
+		r.metrics.addDroppedFrame()
+		r.framesDropped++
+	}
+
 	r.buf.Reset()
 	_, _ = r.buf.WriteString(s)
+	r.nextPanes, r.nextPaneWidths = panes, widths
+	r.pending = true
+	r.written++
+	r.metrics.setFramePending(true)
+
+	// If we've dropped too many frames in a row, don't wait for the next
+	// tick to render this one.
+	if r.maxDroppedFrames > 0 && r.framesDropped >= r.maxDroppedFrames {
+		r.render()
+	}
+}
+
+// markDrawn records that the frame in the buffer has been dealt with, either
+// drawn or found to be the same as the last, and lets anything waiting for it
+// know. The mutex must be held when calling this.
+func (r *renderer) markDrawn() {
+	r.drawn, r.hold = r.written, false
+	r.metrics.setFramePending(false)
+	if inputLatencyStamps {
+		r.recordInputLatency()
+	}
+	if r.drawnCh != nil {
+		close(r.drawnCh)
+		r.drawnCh = nil
+	}
+}
+
+// waitDrawn waits until frame n, or a later one, has been drawn. It returns
+// false if ctx is done first.
+func (r *renderer) waitDrawn(ctx context.Context, n uint64) bool {
+	for {
+		r.mtx.Lock()
+		if r.drawn >= n {
+			r.mtx.Unlock()
+			return true
+		}
+		if r.drawnCh == nil {
+			r.drawnCh = make(chan struct{})
+		}
+		drawn := r.drawnCh
+		r.mtx.Unlock()
+
+		select {
+		case <-drawn:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// holdFrame makes sure frame n, or a later one, is drawn before the frame in
+// the buffer is replaced. It's called when a command issued alongside frame n
+// finishes.
+func (r *renderer) holdFrame(n uint64) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.drawn < n {
+		r.hold = true
+	}
+}
+
+// truncateFrame cuts s short if it's longer than maxBytes or has more lines
+// than maxLines, ending it with frameTruncatedMarker, and reports whether it
+// did. Escape sequences and runes are never split. Zero or less means no
+// limit.
+func truncateFrame(s string, maxBytes, maxLines int) (string, bool) {
+	end := len(s)
+	if maxLines > 0 {
+		for n, off := 1, 0; ; n++ {
+			i := strings.IndexByte(s[off:], '\n')
+			if i < 0 {
+				break
+			}
+			if n == maxLines {
+				end = off + i
+				break
+			}
+			off += i + 1
+		}
+	}
+	if maxBytes > 0 && end > maxBytes {
+		end = safeCut([]byte(s[:maxBytes]))
+	}
+	if end == len(s) {
+		return s, false
+	}
+	return s[:end] + frameTruncatedMarker, true
+}
+
+// safeCut returns the length of the longest prefix of b which doesn't end part
+// way through an escape sequence or rune. b is assumed to have been cut from
+// something longer, so a sequence running right up to the end of it may be
+// incomplete and isn't included.
+func safeCut(b []byte) int {
+	i := 0
+	for i < len(b) {
+		if n := ansiSeqLen(b[i:]); n > 0 {
+			if i+n >= len(b) {
+				break
+			}
+			i += n
+			continue
+		}
+		if !utf8.FullRune(b[i:]) {
+			break
+		}
+		_, size := utf8.DecodeRune(b[i:])
+		i += size
+	}
+	return i
 }
 
 // setIngoredLines speicifies lines not to be touched by the standard Bubble Tea
@@ -201,6 +679,9 @@ func (r *renderer) setIgnoredLines(from int, to int) {
 	// Erase ignored lines
 	if r.linesRendered > 0 {
 		out := new(bytes.Buffer)
+		if r.resetOnClear {
+			reset(out)
+		}
 		for i := r.linesRendered - 1; i >= 0; i-- {
 			if _, exists := r.ignoreLines[i]; exists {
 				clearLine(out)
@@ -219,6 +700,83 @@ func (r *renderer) clearIgnoredLines() {
 	r.ignoreLines = nil
 }
 
+// setAltScreen records that the alternate screen has been entered or exited.
+// The mutex must be held when calling this.
+func (r *renderer) setAltScreen(active bool) {
+	if active == r.altScreenActive {
+		return
+	}
+	r.altScreenActive = active
+
+	if active {
+		// The alternate screen starts out blank, so there's nothing of ours
+		// to clear.
+		r.mainLinesRendered = r.linesRendered
+		r.linesRendered = 0
+	} else {
+		// The main screen comes back just as we left it.
+		r.linesRendered = r.mainLinesRendered
+	}
+
+	// Either way, the next frame needs to be drawn in full.
+	r.repaint()
+}
+
+// repaint makes sure the next frame is drawn in full, even if the view hasn't
+// changed. The mutex must be held when calling this.
+func (r *renderer) repaint() {
+	if !r.pending && !r.stale {
+		_, _ = r.buf.Write(r.lastRender)
+		r.pending = true
+	}
+	r.forget()
+}
+
+// forget forgets the last frame drawn, so that the next is drawn in full even
+// if it's the same. The mutex must be held when calling this.
+func (r *renderer) forget() {
+	r.lastRender = r.lastRender[:0]
+	r.stale = true
+}
+
+// release renders any pending output and then stops drawing until resume is
+// called, leaving the terminal free for something else to use.
+func (r *renderer) release() {
+	r.flush()
+
+	r.mtx.Lock()
+	r.released = true
+	r.mtx.Unlock()
+}
+
+// resume picks up rendering after release. Whatever we drew before has been
+// scrolled away or drawn over in the meantime, so the next frame is drawn
+// from scratch.
+func (r *renderer) resume() {
+	r.mtx.Lock()
+	r.released = false
+	r.linesRendered = 0
+	r.mainLinesRendered = 0
+	r.repaint()
+	r.mtx.Unlock()
+
+	r.updateCursor()
+}
+
+// updateCursor shows or hides the cursor. The cursor is visible only if the
+// program asked for it and, when we're parking the cursor, the terminal has
+// focus.
+func (r *renderer) updateCursor() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.cursorHidden || (r.parkCursorOnBlur && r.blurred) {
+		hideCursor(r.out)
+	} else {
+		showCursor(r.out)
+	}
+}
+
 // insertTop effectively scrolls up. It inserts lines at the top of a given
 // area designated to be a scrollable region, pushing everything else down.
 // This is roughly how ncurses does it.
@@ -283,10 +841,27 @@ func (r *renderer) insertBottom(lines []string, topBoundary, bottomBoundary int)
 
 // handleMessages handles internal messages for the renderer.
 func (r *renderer) handleMessages(msg Msg) {
+	// High-performance rendering works by moving the cursor around, so in
+	// accessible mode we leave it out entirely.
+	if r.accessible {
+		switch msg.(type) {
+		case clearScrollAreaMsg, syncScrollAreaMsg, scrollUpMsg, scrollDownMsg,
+			scrollScreenUpMsg, scrollScreenDownMsg:
+			return
+		}
+	}
+
 	switch msg := msg.(type) {
 	case WindowSizeMsg:
+		r.mtx.Lock()
+		if (r.padLines || r.softWrap) && msg.Width != r.width {
+			// Lines need padding or wrapping to the new width, even if the
+			// view hasn't changed.
+			r.forget()
+		}
 		r.width = msg.Width
 		r.height = msg.Height
+		r.mtx.Unlock()
 
 	case clearScrollAreaMsg:
 		r.clearIgnoredLines()
@@ -294,7 +869,7 @@ func (r *renderer) handleMessages(msg Msg) {
 		// Force a repaint on the area where the scrollable stuff was in this
 		// update cycle
 		r.mtx.Lock()
-		r.lastRender = ""
+		r.forget()
 		r.mtx.Unlock()
 
 	case syncScrollAreaMsg:
@@ -305,7 +880,7 @@ func (r *renderer) handleMessages(msg Msg) {
 
 		// Force non-scrolling stuff to repaint in this update cycle
 		r.mtx.Lock()
-		r.lastRender = ""
+		r.forget()
 		r.mtx.Unlock()
 
 	case scrollUpMsg:
@@ -313,6 +888,50 @@ func (r *renderer) handleMessages(msg Msg) {
 
 	case scrollDownMsg:
 		r.insertBottom(msg.lines, msg.topBoundary, msg.bottomBoundary)
+
+	case scrollScreenUpMsg:
+		r.mtx.Lock()
+		scrollScreenUp(r.out, msg.n)
+
+		// The cursor stays put while what we drew moves up, so the lines
+		// between the two are ours to clear next time too.
+		r.linesRendered += msg.n
+		r.mtx.Unlock()
+
+	case scrollScreenDownMsg:
+		r.mtx.Lock()
+		scrollScreenDown(r.out, msg.n)
+
+		// Follow what we drew back down, so that the cursor's on our last
+		// line as usual.
+		cursorDownBy(r.out, msg.n)
+		r.mtx.Unlock()
+
+	case enableFrameMsgsMsg:
+		r.setFrameMsgs(true)
+
+	case disableFrameMsgsMsg:
+		r.setFrameMsgs(false)
+
+	case hideCursorMsg:
+		r.cursorHidden = true
+		r.updateCursor()
+
+	case showCursorMsg:
+		r.cursorHidden = false
+		r.updateCursor()
+
+	case FocusMsg:
+		r.blurred = false
+		if r.parkCursorOnBlur {
+			r.updateCursor()
+		}
+
+	case BlurMsg:
+		r.blurred = true
+		if r.parkCursorOnBlur {
+			r.updateCursor()
+		}
 	}
 }
 
@@ -390,3 +1009,38 @@ func ScrollDown(newLines []string, topBoundary, bottomBoundary int) Cmd {
 		}
 	}
 }
+
+type scrollScreenUpMsg struct {
+	n int
+}
+
+// ScrollScreenUp scrolls the whole visible screen up by n lines, leaving blank
+// lines at the bottom, as if that many lines had been printed. Unlike ScrollUp
+// this doesn't involve a scrollable region; everything on screen moves,
+// including the program's own output, which carries on rendering from where
+// it ends up.
+func ScrollScreenUp(n int) Cmd {
+	if n <= 0 {
+		return nil
+	}
+	return func() Msg {
+		return scrollScreenUpMsg{n: n}
+	}
+}
+
+type scrollScreenDownMsg struct {
+	n int
+}
+
+// ScrollScreenDown scrolls the whole visible screen down by n lines, leaving
+// blank lines at the top. Anything pushed off the bottom of the screen is
+// lost. Unlike ScrollDown this doesn't involve a scrollable region;
+// everything on screen moves, including the program's own output.
+func ScrollScreenDown(n int) Cmd {
+	if n <= 0 {
+		return nil
+	}
+	return func() Msg {
+		return scrollScreenDownMsg{n: n}
+	}
+}
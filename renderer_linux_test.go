@@ -0,0 +1,77 @@
+// +build linux
+
+package tea
+
+import (
+	"testing"
+	"time"
+)
+
+// runOnPTY runs a program drawing view inline to a pseudo-terminal until its
+// size is reported, and returns everything it wrote. Frames are only drawn
+// when the program stops, as the clock never moves on.
+func runOnPTY(t *testing.T, view string) string {
+	t.Helper()
+	pty := openTestPTY(t, 80, 24)
+	p := NewProgram(
+		func() (Model, Cmd) { return 0, nil },
+		func(msg Msg, m Model) (Model, Cmd) {
+			if _, ok := msg.(WindowSizeMsg); ok {
+				return m, Quit
+			}
+			return m, nil
+		},
+		func(Model) string { return view },
+		WithInput(nil),
+		WithOutput(pty.tty),
+		WithClock(NewTestClock(time.Now())),
+	)
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return pty.close()
+}
+
+func TestInlineExit(t *testing.T) {
+	tests := []struct {
+		name string
+		view string
+		want string
+	}{
+		{
+			// One line break takes the cursor below the frame.
+			name: "no trailing newline",
+			view: "hello",
+			want: "\x1b[?25l" + "hello" + "\x1b[80D" + "\r\n" + "\x1b[?25h",
+		},
+		{
+			// The cursor's already below the frame, so nothing's added.
+			name: "trailing newline",
+			view: "hello\n",
+			want: "\x1b[?25l" + "hello\r\n" + "\x1b[80D" + "\x1b[?25h",
+		},
+		{
+			name: "several lines",
+			view: "one\ntwo",
+			want: "\x1b[?25l" + "one\r\ntwo" + "\x1b[80D" + "\r\n" + "\x1b[?25h",
+		},
+		{
+			// A last line that's only styling leaves nothing to move past.
+			name: "styled blank last line",
+			view: "one\n\x1b[0m",
+			want: "\x1b[?25l" + "one\r\n\x1b[0m" + "\x1b[80D" + "\x1b[?25h",
+		},
+		{
+			name: "empty view",
+			view: "",
+			want: "\x1b[?25l" + "\x1b[?25h",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runOnPTY(t, tt.view); got != tt.want {
+				t.Errorf("got  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
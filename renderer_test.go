@@ -0,0 +1,516 @@
+package tea
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// softWrapGolden is how lines are soft-wrapped at different widths, with the
+// number of extra rows each takes up.
+var softWrapGolden = []struct {
+	width int
+	line  string
+	want  string
+	extra int
+}{
+	// Breaking between words.
+	{5, "the quick brown fox", "the\r\nquick\r\nbrown\r\nfox", 3},
+	{10, "the quick brown fox", "the quick\r\nbrown fox", 1},
+	{80, "the quick brown fox", "the quick brown fox", 0},
+
+	// Hard breaks in runs too long to fit on a line.
+	{5, "abcdefghijklmnop", "abcde\r\nfghij\r\nklmno\r\np", 3},
+	{8, "abcdefghijklmnop", "abcdefgh\r\nijklmnop", 1},
+	{5, "hi abcdefghijklmnop yo", "hi\r\nabcde\r\nfghij\r\nklmno\r\np yo", 4},
+	{10, "hi abcdefghijklmnop yo", "hi\r\nabcdefghij\r\nklmnop yo", 2},
+
+	// Wide runes at the edge are moved down whole rather than split.
+	{5, "日本語のテキスト", "日本\r\n語の\r\nテキ\r\nスト", 3},
+	{8, "日本語のテキスト", "日本語の\r\nテキスト", 1},
+	{10, "日本語のテキスト", "日本語のテ\r\nキスト", 1},
+	{4, "a日本語", "a日\r\n本語", 1},
+	{5, "abcd日本", "abcd\r\n日本", 1},
+	{8, "abcd日本", "abcd日本", 0},
+	{5, "ab 日本語 cd", "ab\r\n日本\r\n語 cd", 2},
+	{8, "ab 日本語 cd", "ab\r\n日本語\r\ncd", 2},
+	{10, "ab 日本語 cd", "ab 日本語\r\ncd", 1},
+
+	// Styling is carried over onto the next line and reset at the break.
+	{5, "\x1b[31mred words\x1b[0m and more", "\x1b[31mred\x1b[0m\r\n\x1b[31mwords\x1b[0m\r\nand\r\nmore", 3},
+	{8, "\x1b[31mred words\x1b[0m and more", "\x1b[31mred\x1b[0m\r\n\x1b[31mwords\x1b[0m\r\nand more", 2},
+	{10, "\x1b[31mred words\x1b[0m and more", "\x1b[31mred words\x1b[0m\r\nand more", 1},
+}
+
+func TestSoftWrap(t *testing.T) {
+	for _, tt := range softWrapGolden {
+		var out bytes.Buffer
+		r := newRenderer(&out, &sync.Mutex{})
+		r.width, r.softWrap = tt.width, true
+
+		extra := r.writeLine(&out, []byte(tt.line))
+		if got := out.String(); got != tt.want || extra != tt.extra {
+			t.Errorf("width %d, %q: got %q with %d extra rows, want %q with %d",
+				tt.width, tt.line, got, extra, tt.want, tt.extra)
+		}
+	}
+}
+
+func TestSoftWrapResize(t *testing.T) {
+	tp := startTestProgram(t, WithSoftWrap())
+	defer tp.stop()
+
+	tp.Send(WindowSizeMsg{Width: 10, Height: 24})
+	tp.expect(WindowSizeMsg{Width: 10, Height: 24})
+	tp.draw("the quick brown fox\nend")
+	tp.expectScreen("the quick\nbrown fox\nend")
+
+	// Going wider wraps the paragraph again, with nothing left over from
+	// the rows it took up before.
+	tp.Send(WindowSizeMsg{Width: 20, Height: 24})
+	tp.expect(WindowSizeMsg{Width: 20, Height: 24})
+	tp.draw("the quick brown fox\nend")
+	tp.expectScreen("the quick brown fox\nend")
+
+	tp.Send(WindowSizeMsg{Width: 5, Height: 24})
+	tp.expect(WindowSizeMsg{Width: 5, Height: 24})
+	tp.draw("the quick brown fox\nend")
+	tp.expectScreen("the\nquick\nbrown\nfox\nend")
+
+	// A shorter frame clears the rows the wrapped one took up.
+	tp.draw("short")
+	tp.expectScreen("short")
+}
+
+// colorView returns a view of n lines, every word in it colored, with seed
+// changing what's on each line so that successive frames differ.
+func colorView(n, seed int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		for j := 0; j < 8; j++ {
+			fmt.Fprintf(&b, "\x1b[38;5;%dmword%d\x1b[0m ", (i+j+seed)%256, i*j+seed)
+		}
+	}
+	return b.String()
+}
+
+func BenchmarkRenderColorView(b *testing.B) {
+	r := newRenderer(ioutil.Discard, &sync.Mutex{})
+	r.width, r.height = 120, 200
+	frames := []string{colorView(100, 0), colorView(100, 1)}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.write(frames[i%2])
+		r.flush()
+	}
+}
+
+func TestRenderAllocs(t *testing.T) {
+	r := newRenderer(ioutil.Discard, &sync.Mutex{})
+	r.width, r.height = 120, 200
+	frames := []string{colorView(100, 0), colorView(100, 1)}
+
+	// Once the buffers have grown to fit, drawing a frame shouldn't
+	// allocate at all.
+	var n int
+	for i := 0; i < 10; i++ {
+		r.write(frames[i%2])
+		r.flush()
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		r.write(frames[n%2])
+		r.flush()
+		n++
+	})
+	if allocs > 0 {
+		t.Errorf("got %v allocations per frame, want 0", allocs)
+	}
+}
+
+// slowWriter is output that takes a while to write to, like a terminal that
+// can't keep up.
+type slowWriter struct {
+	delay time.Duration
+}
+
+func (w slowWriter) Write(b []byte) (int, error) {
+	time.Sleep(w.delay)
+	return len(b), nil
+}
+
+// StormMsg is sent over and over, to give a program more to do than it can
+// draw.
+type StormMsg struct{}
+
+// frameStorm runs a program that draws a new frame for each of n messages
+// sent in quick succession, writing its frames to a slow writer and never
+// moving the clock on until they've all been handled. It returns the frames
+// that were written during the storm, and then those written after the clock's
+// moved on by a frame.
+func frameStorm(t *testing.T, n int, opts ...ProgramOption) (storm, after []string, m ProgramMetrics) {
+	t.Helper()
+	var (
+		mtx    sync.Mutex
+		frames []string
+		errc   = make(chan error, 1)
+		clock  = NewTestClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	)
+	p := NewProgram(
+		func() (Model, Cmd) { return 0, nil },
+		nil,
+		func(m Model) string { return fmt.Sprintf("frame %d", m) },
+		append([]ProgramOption{
+			// Only storm messages lead to new frames.
+			WithHandler(StormMsg{}, func(msg Msg, m Model) (Model, Cmd) {
+				return m.(int) + 1, nil
+			}),
+			WithInput(nil),
+			WithOutput(slowWriter{delay: time.Millisecond}),
+			WithClock(clock),
+			WithFrameObserver(func(frame string, _ int) {
+				mtx.Lock()
+				frames = append(frames, frame)
+				mtx.Unlock()
+			}),
+		}, opts...)...,
+	)
+	go func() {
+		errc <- p.Start()
+	}()
+
+	for i := 0; i < n; i++ {
+		// The program may not have started yet, with only so much room
+		// for messages until it has.
+		for p.InjectMsg(StormMsg{}) == ErrStartupQueueFull {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	// Wait for the last frame to be handed to the renderer, after the first.
+	for deadline := time.Now().Add(testTimeout); p.Metrics().TotalFrames < uint64(n+1); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("storm wasn't handled: %+v", p.Metrics())
+		}
+	}
+	mtx.Lock()
+	storm = frames
+	frames = nil
+	mtx.Unlock()
+
+	// Give the renderer a tick, and wait for it to be dealt with.
+	clock.BlockUntil(1)
+	clock.Advance(defaultFramerate)
+	for deadline := time.Now().Add(testTimeout); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if !p.Metrics().FramePending {
+			break
+		}
+	}
+	mtx.Lock()
+	after = frames
+	mtx.Unlock()
+	m = p.Metrics()
+
+	p.Send(Quit())
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	return storm, after, m
+}
+
+func TestFrameStorm(t *testing.T) {
+	const n = 100
+	storm, after, m := frameStorm(t, n)
+
+	// With no ticks nothing's drawn until the storm's over, and then only
+	// the last frame is.
+	if len(storm) != 0 {
+		t.Errorf("%d frames drawn during the storm, want none", len(storm))
+	}
+	if want := []string{fmt.Sprintf("frame %d", n)}; !reflect.DeepEqual(after, want) {
+		t.Errorf("drawn after the storm: %q, want %q", after, want)
+	}
+	// Every frame but the last was dropped, the first included.
+	if m.DroppedFrames != n {
+		t.Errorf("%d frames dropped, want %d", m.DroppedFrames, n)
+	}
+	if m.TotalRenders != 1 {
+		t.Errorf("%d frames rendered, want 1", m.TotalRenders)
+	}
+}
+
+func TestFrameStormMaxDropped(t *testing.T) {
+	const n, max = 100, 4
+	storm, after, m := frameStorm(t, n, WithMaxDroppedFrames(max))
+
+	// At least one in every max+1 frames is drawn, without waiting for a
+	// tick.
+	last := -1
+	for _, f := range storm {
+		var i int
+		if _, err := fmt.Sscanf(f, "frame %d", &i); err != nil {
+			t.Fatal(err)
+		}
+		if i-last > max+1 {
+			t.Errorf("frames %d to %d dropped, more than %d in a row", last+1, i-1, max)
+		}
+		last = i
+	}
+	if n-last > max+1 {
+		t.Errorf("last frame drawn during the storm was %d of %d", last, n)
+	}
+
+	// The last frame's drawn on the next tick, unless it already has been.
+	if want := fmt.Sprintf("frame %d", n); last != n && !reflect.DeepEqual(after, []string{want}) {
+		t.Errorf("drawn after the storm: %q, want %q", after, want)
+	}
+	if got := m.TotalRenders + m.DroppedFrames; got != n+1 {
+		t.Errorf("%d frames rendered and %d dropped, want %d in all", m.TotalRenders, m.DroppedFrames, n+1)
+	}
+	if m.DroppedFrames > n*max/(max+1)+1 {
+		t.Errorf("%d frames dropped, more than %d in every %d", m.DroppedFrames, max, max+1)
+	}
+}
+
+func TestStrictRendering(t *testing.T) {
+	payloads := []struct {
+		name string
+		seq  string
+	}{
+		{"clear screen", "\x1b[2J"},
+		{"cursor home", "\x1b[H"},
+		{"window title", "\x1b]0;pwned\x07"},
+		{"window title with ST", "\x1b]2;pwned\x1b\\"},
+		{"bell", "\x07"},
+		{"bare carriage return", "\r"},
+	}
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	for _, tt := range payloads {
+		t.Run(tt.name, func(t *testing.T) {
+			logged.Reset()
+			var out bytes.Buffer
+			r := newRenderer(&out, &sync.Mutex{})
+			r.width, r.strict = 80, true
+			r.write("ok")
+			r.flush()
+
+			// The frame with the payload in it is dropped whole, leaving
+			// the last one on screen.
+			r.write("file: " + tt.seq + "contents")
+			r.flush()
+			if got, want := out.String(), "ok\x1b[80D"; got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+			if !strings.Contains(logged.String(), "dropped frame with unsafe control characters at byte 6") {
+				t.Errorf("logged %q", logged.String())
+			}
+
+			// Styled text is still fine.
+			r.write("\x1b[1mbold\x1b[0m")
+			r.flush()
+			if got, want := out.String(), "ok\x1b[80D"+"\x1b[80D\x1b[2K\x1b[1mbold\x1b[0m\x1b[80D"; got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestEmptyFrame(t *testing.T) {
+	steps := []struct {
+		view  string
+		want  string
+		lines int
+	}{
+		{"one\ntwo", "one\r\ntwo\x1b[80D", 2},
+		// Both lines are cleared, leaving the cursor where the frame
+		// started.
+		{"", "\x1b[2K\x1b[1A\x1b[80D\x1b[2K", 0},
+		// There's nothing to clear, so the frame's drawn straight away.
+		{"three", "three\x1b[80D", 1},
+		{"", "\x1b[80D\x1b[2K", 0},
+		{"", "", 0},
+		{"four\nfive", "four\r\nfive\x1b[80D", 2},
+	}
+	var out bytes.Buffer
+	r := newRenderer(&out, &sync.Mutex{})
+	r.width = 80
+	for _, s := range steps {
+		out.Reset()
+		r.write(s.view)
+		r.flush()
+		if got := out.String(); got != s.want || r.linesRendered != s.lines {
+			t.Errorf("%q: got %q over %d lines, want %q over %d", s.view, got, r.linesRendered, s.want, s.lines)
+		}
+	}
+}
+
+func TestScrollScreen(t *testing.T) {
+	// Everything drawn moves along with the screen, and the next frame's
+	// drawn over the old one wherever it's got to. The screen starts with
+	// a few lines from the shell.
+	steps := []struct {
+		name   string
+		do     func(r *renderer)
+		want   string
+		lines  int
+		screen []string
+	}{
+		{
+			name:   "draw",
+			do:     func(r *renderer) { r.write("one\ntwo"); r.flush() },
+			want:   "one\r\ntwo\x1b[80D",
+			lines:  2,
+			screen: []string{"$ a", "$ b", "$ c", "one", "two", ""},
+		},
+		{
+			// The frame's moved up from under the cursor, so the lines
+			// in between are counted as the frame's, to be cleared.
+			name:   "scroll up",
+			do:     func(r *renderer) { r.handleMessages(scrollScreenUpMsg{2}) },
+			want:   "\x1b[2S",
+			lines:  4,
+			screen: []string{"$ c", "one", "two", "", "", ""},
+		},
+		{
+			name:   "draw after scrolling up",
+			do:     func(r *renderer) { r.write("three"); r.flush() },
+			want:   "\x1b[2K\x1b[1A\x1b[2K\x1b[1A\x1b[2K\x1b[1A\x1b[80D\x1b[2Kthree\x1b[80D",
+			lines:  1,
+			screen: []string{"$ c", "three", "", "", "", ""},
+		},
+		{
+			// The cursor follows the frame down instead.
+			name:   "scroll down",
+			do:     func(r *renderer) { r.handleMessages(scrollScreenDownMsg{1}) },
+			want:   "\x1b[1T\x1b[1B",
+			lines:  1,
+			screen: []string{"", "$ c", "three", "", "", ""},
+		},
+		{
+			name:   "draw after scrolling down",
+			do:     func(r *renderer) { r.write("four\nfive"); r.flush() },
+			want:   "\x1b[80D\x1b[2Kfour\r\nfive\x1b[80D",
+			lines:  2,
+			screen: []string{"", "$ c", "four", "five", "", ""},
+		},
+	}
+
+	var out bytes.Buffer
+	term := NewVirtualTerminal(80, 6)
+	_, _ = io.WriteString(term, "$ a\r\n$ b\r\n$ c\r\n")
+	r := newRenderer(io.MultiWriter(&out, term), &sync.Mutex{})
+	r.width, r.height = term.Size()
+	for _, s := range steps {
+		out.Reset()
+		s.do(r)
+		if got := out.String(); got != s.want || r.linesRendered != s.lines {
+			t.Errorf("%s: got %q over %d lines, want %q over %d", s.name, got, r.linesRendered, s.want, s.lines)
+		}
+		if got, want := term.String(), strings.Join(s.screen, "\n"); got != want {
+			t.Errorf("%s: screen\n%s\nwant\n%s", s.name, got, want)
+		}
+	}
+}
+
+func TestWideRuneLastColumn(t *testing.T) {
+	tests := []struct {
+		line  string
+		want  string
+		extra int
+	}{
+		// The wide rune would start in the last column, so it goes on
+		// the next row, with the last column left blank.
+		{"abcd日本", "abcd \r\n日本", 1},
+		{"日本語", "日本 \r\n語", 1},
+		{"\x1b[31mabcd日\x1b[0m", "\x1b[31mabcd \r\n日\x1b[0m", 1},
+		// Around it, wide runes fit as they are.
+		{"abc日本", "abc日\r\n本", 1},
+		{"abcde日", "abcde\r\n日", 1},
+		{"abc日", "abc日", 0},
+		{"abcd日本語のテ", "abcd \r\n日本 \r\n語の \r\nテ", 3},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		r := newRenderer(&out, &sync.Mutex{})
+		r.width = 5
+		extra := r.writeLine(&out, []byte(tt.line))
+		if got := out.String(); got != tt.want || extra != tt.extra {
+			t.Errorf("%q: got %q with %d extra rows, want %q with %d", tt.line, got, extra, tt.want, tt.extra)
+		}
+	}
+
+	// The rows the line takes up are all accounted for, so the next frame
+	// clears every one of them.
+	var out bytes.Buffer
+	r := newRenderer(&out, &sync.Mutex{})
+	r.width = 5
+	r.write("abcd日本\nx")
+	r.flush()
+	if want := "abcd \r\n日本\r\nx\x1b[5D"; out.String() != want || r.linesRendered != 3 {
+		t.Errorf("got %q over %d lines, want %q over 3", out.String(), r.linesRendered, want)
+	}
+	out.Reset()
+	r.write("y")
+	r.flush()
+	if want := "\x1b[2K\x1b[1A\x1b[2K\x1b[1A\x1b[5D\x1b[2Ky\x1b[5D"; out.String() != want || r.linesRendered != 1 {
+		t.Errorf("got %q over %d lines, want %q over 1", out.String(), r.linesRendered, want)
+	}
+}
+
+func TestAltScreenRepaint(t *testing.T) {
+	var out bytes.Buffer
+	r := newRenderer(&out, &sync.Mutex{})
+	r.width = 80
+	draw := func(view string) string {
+		out.Reset()
+		r.write(view)
+		r.flush()
+		return out.String()
+	}
+	setAltScreen := func(active bool) {
+		r.mtx.Lock()
+		r.setAltScreen(active)
+		r.mtx.Unlock()
+	}
+
+	if got, want := draw("one\ntwo"), "one\r\ntwo\x1b[80D"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := draw("one\ntwo"); got != "" {
+		t.Errorf("unchanged view drew %q", got)
+	}
+
+	// The alternate screen starts out blank, so the same view is drawn in
+	// full, with nothing to clear first.
+	setAltScreen(true)
+	if got, want := draw("one\ntwo"), "one\r\ntwo\x1b[2;0H"; got != want {
+		t.Errorf("entering the alternate screen: got %q, want %q", got, want)
+	}
+	if got, want := draw("alt"), "\x1b[2K\x1b[1A\x1b[80D\x1b[2Kalt\x1b[1;0H"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Back on the main screen, what was drawn there before is cleared and
+	// the view drawn in full, unchanged as it is.
+	setAltScreen(false)
+	if got, want := draw("alt"), "\x1b[2K\x1b[1A\x1b[80D\x1b[2Kalt\x1b[80D"; got != want {
+		t.Errorf("leaving the alternate screen: got %q, want %q", got, want)
+	}
+	if r.linesRendered != 1 {
+		t.Errorf("%d lines rendered, want 1", r.linesRendered)
+	}
+}
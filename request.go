@@ -0,0 +1,67 @@
+package tea
+
+import "sync/atomic"
+
+// requestSeq numbers requests in the order they're made. atomic.
+var requestSeq uint64
+
+// Request tags a command with an ID. On its own this changes nothing, but
+// when the program is created with WithDropStaleRequests only the result of
+// the most recent request with a given ID is delivered to Update; results of
+// earlier requests with that ID which arrive late are dropped.
+//
+// This is useful for things like searching as the user types, where each
+// keystroke issues a query and the results can come back in any order:
+//
+//   case KeyMsg:
+//       m.query += msg.String()
+//       return m, Request("search", search(m.query))
+//
+// The ID can be any comparable value.
+func Request(id interface{}, cmd Cmd) Cmd {
+	if cmd == nil {
+		return nil
+	}
+	seq := atomic.AddUint64(&requestSeq, 1)
+	return func() Msg {
+		return requestMsg{id: id, seq: seq, cmd: cmd}
+	}
+}
+
+// requestMsg is an internal message used to dispatch a tagged command. You can
+// send a requestMsg with Request.
+type requestMsg struct {
+	id     interface{}
+	seq    uint64
+	cmd    Cmd
+	origin origin // where the request came from, filled in when it's run
+}
+
+// requestResultMsg is an internal message carrying the result of a tagged
+// command.
+type requestResultMsg struct {
+	id  interface{}
+	seq uint64
+	msg Msg
+}
+
+// requestTable tracks the most recent request made for each ID. Entries are
+// removed once that request's result has been delivered.
+type requestTable map[interface{}]uint64
+
+// issue records that a request was made.
+func (t requestTable) issue(id interface{}, seq uint64) {
+	if seq > t[id] {
+		t[id] = seq
+	}
+}
+
+// deliver reports whether a request's result should be delivered, which is
+// only the case if it's the most recent request for its ID.
+func (t requestTable) deliver(id interface{}, seq uint64) bool {
+	if latest, ok := t[id]; !ok || latest != seq {
+		return false
+	}
+	delete(t, id)
+	return true
+}
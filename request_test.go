@@ -0,0 +1,57 @@
+package tea
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRequestDeferredCmds(t *testing.T) {
+	second := TickedMsg(time.Date(2020, 1, 1, 0, 0, 1, 0, time.UTC))
+	tests := []struct {
+		name  string
+		cmd   Cmd
+		timer int
+		want  Msg
+	}{
+		{"Tick", Tick(time.Second, tickFn), 1, second},
+		{"CmdWithContext", CmdWithContext(func(context.Context) Msg { return "done" }), 0, "done"},
+		{"FrameTick", FrameTick(time.Second, tickFn), 1, second},
+	}
+
+	for _, stale := range []bool{false, true} {
+		for _, tt := range tests {
+			name := tt.name
+			var opts []ProgramOption
+			if stale {
+				name += " dropping stale requests"
+				opts = append(opts, WithDropStaleRequests())
+			}
+			t.Run(name, func(t *testing.T) {
+				p := startTestProgram(t, opts...)
+				defer p.stop()
+
+				p.run(Request("id", tt.cmd))
+				if tt.timer > 0 {
+					p.advance(tt.timer, time.Second)
+				}
+				p.expect(tt.want)
+			})
+		}
+	}
+}
+
+func TestRequestDropsStaleTick(t *testing.T) {
+	p := startTestProgram(t, WithDropStaleRequests())
+	defer p.stop()
+
+	// The first request finishes after the second, so its result is stale.
+	p.run(Request("id", Tick(2*time.Second, tickFn)))
+	p.advance(1, 0)
+	p.run(Request("id", Tick(time.Second, tickFn)))
+	p.advance(2, time.Second)
+	p.expect(TickedMsg(time.Date(2020, 1, 1, 0, 0, 1, 0, time.UTC)))
+
+	p.clock.Advance(time.Second)
+	p.expectNone(50 * time.Millisecond)
+}
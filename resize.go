@@ -0,0 +1,70 @@
+package tea
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// defaultResizePollInterval is how often the terminal size is polled when we
+// can't be told about resizes. 4 Hz keeps up with someone dragging a window
+// around without costing anything noticeable.
+const defaultResizePollInterval = 250 * time.Millisecond
+
+// resizeState is the last terminal size we reported. However we find out
+// about a resize, we only send a WindowSizeMsg when the size has actually
+// changed.
+type resizeState struct {
+	mtx   sync.Mutex
+	known bool
+	last  WindowSizeMsg
+}
+
+// checkResize looks up the size of the terminal and sends a WindowSizeMsg if
+// it's changed since we last reported it.
+func (p *Program) checkResize(f *os.File) {
+	// Hold the lock while sending so that sizes are delivered in the order
+	// they were read.
+	p.size.mtx.Lock()
+	defer p.size.mtx.Unlock()
+
+	w, h, err := terminal.GetSize(int(f.Fd()))
+	if err != nil {
+		select {
+		case p.errs <- err:
+		case <-p.done:
+		}
+		return
+	}
+
+	size := WindowSizeMsg{w, h}
+	if p.size.known && size == p.size.last {
+		return
+	}
+	p.size.known, p.size.last = true, size
+
+	select {
+	case p.msgs <- size:
+	case <-p.done:
+	}
+}
+
+// pollResize checks the size of the terminal at the given interval until the
+// program shuts down. Polling is paused while the terminal is released.
+func (p *Program) pollResize(f *os.File, interval time.Duration) {
+	t := p.clock.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-t.C():
+			if !p.terminalReleased() {
+				p.checkResize(f)
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package tea
+
+// RingBuffer holds the most recent items pushed to it, up to a fixed
+// capacity. Once it's full, pushing an item discards the oldest one. It's
+// handy for keeping things like logs, message history and undo stacks in a
+// model without them growing forever.
+//
+// Items are stored as interface{} values, so you'll need to convert them back
+// to their own type:
+//
+//   for _, item := range m.log.Last(10) {
+//       line := item.(string)
+//   }
+//
+// A RingBuffer isn't safe for concurrent use, but as models are only touched
+// from Update that's rarely a concern.
+type RingBuffer struct {
+	items []interface{}
+	start int // index of the oldest item
+	count int
+}
+
+// NewRingBuffer returns a RingBuffer which holds up to capacity items. A
+// capacity of less than one is treated as one.
+func NewRingBuffer(capacity int) *RingBuffer {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &RingBuffer{items: make([]interface{}, capacity)}
+}
+
+// Push adds an item, discarding the oldest item if the buffer is full.
+func (b *RingBuffer) Push(item interface{}) {
+	if b.count < len(b.items) {
+		b.items[(b.start+b.count)%len(b.items)] = item
+		b.count++
+		return
+	}
+	b.items[b.start] = item
+	b.start = (b.start + 1) % len(b.items)
+}
+
+// All returns every item in the buffer, oldest first. The returned slice is a
+// copy and can be modified freely.
+func (b *RingBuffer) All() []interface{} {
+	return b.Last(b.count)
+}
+
+// Last returns the n most recent items, oldest first. If there are fewer than
+// n items, all of them are returned. The returned slice is a copy and can be
+// modified freely.
+func (b *RingBuffer) Last(n int) []interface{} {
+	if n > b.count {
+		n = b.count
+	}
+	if n < 0 {
+		n = 0
+	}
+	out := make([]interface{}, n)
+	for i := range out {
+		out[i] = b.items[(b.start+b.count-n+i)%len(b.items)]
+	}
+	return out
+}
+
+// Len returns the number of items in the buffer.
+func (b *RingBuffer) Len() int {
+	return b.count
+}
+
+// Cap returns the most items the buffer can hold.
+func (b *RingBuffer) Cap() int {
+	return len(b.items)
+}
@@ -3,20 +3,57 @@ package tea
 import (
 	"fmt"
 	"io"
+	"strings"
 
 	te "github.com/muesli/termenv"
 )
 
+// Sequences we write for every line of every frame are spelled out up front
+// so that writing them doesn't allocate.
+const (
+	clearLineSeq  = te.CSI + "2K"
+	cursorUpSeq   = te.CSI + "1A"
+	cursorDownSeq = te.CSI + "1B"
+	resetSeq      = te.CSI + te.ResetSeq + "m"
+)
+
+func reset(w io.Writer) {
+	_, _ = io.WriteString(w, resetSeq)
+}
+
+// spaces is written out in chunks to pad lines without allocating.
+var spaces = strings.Repeat(" ", 256)
+
 func clearLine(w io.Writer) {
-	fmt.Fprintf(w, te.CSI+te.EraseLineSeq, 2)
+	_, _ = io.WriteString(w, clearLineSeq)
 }
 
 func cursorUp(w io.Writer) {
-	fmt.Fprintf(w, te.CSI+te.CursorUpSeq, 1)
+	_, _ = io.WriteString(w, cursorUpSeq)
 }
 
 func cursorDown(w io.Writer) {
-	fmt.Fprintf(w, te.CSI+te.CursorDownSeq, 1)
+	_, _ = io.WriteString(w, cursorDownSeq)
+}
+
+func cursorDownBy(w io.Writer, n int) {
+	fmt.Fprintf(w, te.CSI+te.CursorDownSeq, n)
+}
+
+func cursorUpBy(w io.Writer, n int) {
+	fmt.Fprintf(w, te.CSI+te.CursorUpSeq, n)
+}
+
+func cursorColumn(w io.Writer, col int) {
+	fmt.Fprintf(w, te.CSI+te.CursorHorizontalSeq, col)
+}
+
+func scrollScreenUp(w io.Writer, n int) {
+	fmt.Fprintf(w, te.CSI+"%dS", n)
+}
+
+func scrollScreenDown(w io.Writer, n int) {
+	fmt.Fprintf(w, te.CSI+"%dT", n)
 }
 
 func insertLine(w io.Writer, numLines int) {
@@ -34,3 +71,11 @@ func changeScrollingRegion(w io.Writer, top, bottom int) {
 func cursorBack(w io.Writer, n int) {
 	fmt.Fprintf(w, te.CSI+te.CursorBackSeq, n)
 }
+
+func hideCursor(w io.Writer) {
+	_, _ = io.WriteString(w, hideCursorSeq)
+}
+
+func showCursor(w io.Writer) {
+	_, _ = io.WriteString(w, showCursorSeq)
+}
@@ -6,22 +6,41 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
-
-	"golang.org/x/crypto/ssh/terminal"
 )
 
-// listenForResize sends messages (or errors) when the terminal resizes.
-// Argument output should be the file descriptor for the terminal; usually
-// os.Stdout.
-func listenForResize(output *os.File, msgs chan Msg, errs chan error) {
+// canListenForResize reports whether the system tells us when the terminal
+// resizes.
+const canListenForResize = true
+
+// listenForResize calls resized whenever the terminal resizes, until done is
+// closed.
+func listenForResize(done chan struct{}, resized func()) {
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGWINCH)
+	defer signal.Stop(sig)
+
 	for {
-		<-sig
-		w, h, err := terminal.GetSize(int(output.Fd()))
-		if err != nil {
-			errs <- err
+		select {
+		case <-done:
+			return
+		case <-sig:
+			resized()
 		}
-		msgs <- WindowSizeMsg{w, h}
 	}
 }
+
+// listenForTerminate calls terminate if the process is sent SIGTERM before
+// done is closed. It returns once it's listening.
+func listenForTerminate(done chan struct{}, terminate func()) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGTERM)
+
+	go func() {
+		defer signal.Stop(sig)
+		select {
+		case <-done:
+		case <-sig:
+			terminate()
+		}
+	}()
+}
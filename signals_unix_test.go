@@ -0,0 +1,33 @@
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package tea
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestTerminate(t *testing.T) {
+	for _, keys := range [][]string{nil, {"q"}} {
+		tp := startTestProgram(t, WithQuitKeys(keys...))
+
+		// Make sure the program's up and running before asking it to
+		// terminate.
+		tp.run(func() Msg { return DrawMsg{} })
+		tp.expect(DrawMsg{})
+		if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case err := <-tp.errc:
+			if err != nil {
+				t.Errorf("quit keys %q: %v", keys, err)
+			}
+		case <-time.After(testTimeout):
+			t.Fatalf("quit keys %q: program didn't quit", keys)
+		}
+		tp.expectNone(10 * time.Millisecond)
+	}
+}
@@ -2,8 +2,14 @@
 
 package tea
 
-import "os"
+// canListenForResize reports whether the system tells us when the terminal
+// resizes. Windows doesn't implement syscall.SIGWINCH, so we poll instead.
+const canListenForResize = false
 
 // listenForResize is not available on windows because windows does not
 // implement syscall.SIGWINCH.
-func listenForResize(output *os.File, msgs chan Msg, errs chan error) {}
+func listenForResize(done chan struct{}, resized func()) {}
+
+// listenForTerminate does nothing on windows, which has no SIGTERM to listen
+// for.
+func listenForTerminate(done chan struct{}, terminate func()) {}
@@ -0,0 +1,119 @@
+package tea
+
+import (
+	"math"
+	"strings"
+)
+
+// sparkBlocks are the characters sparklines are drawn with, from one eighth
+// of a cell filled to all of it.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// SparklineOption is used to set options when rendering a sparkline with
+// Sparkline.
+type SparklineOption func(*sparklineOptions)
+
+// sparklineOptions is how a sparkline is drawn.
+type sparklineOptions struct {
+	color    string
+	min, max float64
+	fixed    bool
+}
+
+// SparklineColor colors the sparkline. The color is given as for ColorFg.
+func SparklineColor(color string) SparklineOption {
+	return func(o *sparklineOptions) {
+		o.color = color
+	}
+}
+
+// SparklineRange sets the values at the bottom and top of the sparkline, with
+// values outside the range clamped to it. By default the range is that of the
+// values shown, so the sparkline shows how they vary rather than how big they
+// are. A fixed range keeps the scale steady as values come and go, which
+// suits something like CPU usage, going from 0 to 100.
+func SparklineRange(min, max float64) SparklineOption {
+	return func(o *sparklineOptions) {
+		o.min, o.max, o.fixed = min, max, true
+	}
+}
+
+// Sparkline renders data as a small bar chart, width cells wide and height
+// lines tall, with a bar for each value. It's for showing trends at a glance,
+// such as network activity over the last minute:
+//
+//   tea.Sparkline(m.bytesPerSecond, 20, 1)
+//
+// The latest values are on the right. If there are more values than will fit
+// only the latest are shown, and if there are fewer the chart is filled out
+// with blank space on the left. The lowest value is drawn as a sliver rather
+// than nothing, so that every value can be seen. NaNs and infinities are left
+// blank, and don't count towards the range.
+func Sparkline(data []float64, width, height int, opts ...SparklineOption) string {
+	if width <= 0 || height <= 0 {
+		return ""
+	}
+	o := sparklineOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if len(data) > width {
+		data = data[len(data)-width:]
+	}
+	min, max := o.min, o.max
+	if !o.fixed {
+		min, max = math.Inf(1), math.Inf(-1)
+		for _, v := range data {
+			if isFinite(v) {
+				min, max = math.Min(min, v), math.Max(max, v)
+			}
+		}
+	}
+
+	// Work out how many eighths of a cell high each bar is.
+	var (
+		eighths = make([]int, width)
+		steps   = height*len(sparkBlocks) - 1
+		pad     = width - len(data)
+	)
+	for i, v := range data {
+		switch {
+		case !isFinite(v):
+			continue
+		case max > min:
+			n := (math.Min(math.Max(v, min), max) - min) / (max - min)
+			eighths[pad+i] = 1 + int(math.Round(n*float64(steps)))
+		default:
+			eighths[pad+i] = 1
+		}
+	}
+
+	lines := make([]string, height)
+	for row := range lines {
+		var (
+			b     strings.Builder
+			floor = (height - 1 - row) * len(sparkBlocks) // eighths below this row
+		)
+		for _, e := range eighths {
+			switch n := e - floor; {
+			case n <= 0:
+				b.WriteByte(' ')
+			case n >= len(sparkBlocks):
+				b.WriteRune(sparkBlocks[len(sparkBlocks)-1])
+			default:
+				b.WriteRune(sparkBlocks[n-1])
+			}
+		}
+		lines[row] = b.String()
+		if o.color != "" {
+			lines[row] = ColorFg(o.color, lines[row])
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isFinite reports whether v is neither NaN nor infinite.
+func isFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
@@ -0,0 +1,39 @@
+package tea
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSparkline(t *testing.T) {
+	inf := math.Inf(1)
+	tests := []struct {
+		name          string
+		data          []float64
+		width, height int
+		opts          []SparklineOption
+		want          string
+	}{
+		{"scaled", []float64{0, 1, 2, 3, 4, 5, 6, 7}, 8, 1, nil, "▁▂▃▄▅▆▇█"},
+		{"scaled up", []float64{10, 20}, 2, 1, nil, "▁█"},
+		{"two lines", []float64{0, 15}, 2, 2, nil, " █\n▁█"},
+		{"three lines", []float64{0, 8, 23}, 3, 3, nil, "  █\n ▁█\n▁██"},
+		{"too many values", []float64{9, 0, 7}, 2, 1, nil, "▁█"},
+		{"too few values", []float64{0, 7}, 4, 1, nil, "  ▁█"},
+		{"flat", []float64{3, 3, 3}, 3, 1, nil, "▁▁▁"},
+		{"flat on two lines", []float64{3, 3}, 2, 2, nil, "  \n▁▁"},
+		{"no values", nil, 3, 1, nil, "   "},
+		{"NaN", []float64{1, math.NaN(), 5, 3}, 4, 1, nil, "▁ █▅"},
+		{"infinities", []float64{1, inf, 5, -inf}, 4, 1, nil, "▁ █ "},
+		{"nothing finite", []float64{math.NaN(), inf}, 2, 1, nil, "  "},
+		{"fixed range", []float64{50, 150, -5}, 3, 1, []SparklineOption{SparklineRange(0, 100)}, "▅█▁"},
+		{"no room", []float64{1, 2}, 0, 1, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sparkline(tt.data, tt.width, tt.height, tt.opts...); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package tea
+
+import "strings"
+
+// TableOption is used to set options when rendering a table with TableString.
+type TableOption func(*tableOptions)
+
+// tableOptions is how a table is drawn.
+type tableOptions struct {
+	border          BorderStyle
+	headerSeparator bool
+	padding         int
+	maxCellWidth    int
+}
+
+// TableBorder sets the style of the table's border and of the lines between
+// its columns. The default is NormalBorder.
+func TableBorder(style BorderStyle) TableOption {
+	return func(o *tableOptions) {
+		o.border = style
+	}
+}
+
+// TableHeaderSeparator sets whether a line is drawn between the headers and
+// the rows. It's on by default.
+func TableHeaderSeparator(on bool) TableOption {
+	return func(o *tableOptions) {
+		o.headerSeparator = on
+	}
+}
+
+// TablePadding sets how many spaces go either side of each cell's contents.
+// The default is one.
+func TablePadding(n int) TableOption {
+	return func(o *tableOptions) {
+		if n < 0 {
+			n = 0
+		}
+		o.padding = n
+	}
+}
+
+// TableMaxCellWidth cuts cells wider than n cells down to size, ending them
+// with an ellipsis. By default cells are never cut.
+func TableMaxCellWidth(n int) TableOption {
+	return func(o *tableOptions) {
+		o.maxCellWidth = n
+	}
+}
+
+// TableString renders rows as a table with a border around it and lines
+// between its columns, with headers, if there are any, along the top. Columns
+// are as wide as their widest cell and contents are aligned to the left. Rows
+// with fewer cells than others are filled out with empty ones.
+//
+// As with Pad, escape sequences don't count towards the width of a cell, so
+// styled text lines up as it should. Cells are expected to be single lines;
+// any line breaks in them are replaced with spaces.
+//
+// This is meant for showing some data, not for building an interactive table:
+// there's no scrolling, sorting or selection.
+func TableString(rows [][]string, headers []string, opts ...TableOption) string {
+	o := tableOptions{border: NormalBorder, headerSeparator: true, padding: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	cols := len(headers)
+	for _, row := range rows {
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+	if cols == 0 {
+		return ""
+	}
+
+	// Tidy up the cells and work out how wide each column is.
+	widths := make([]int, cols)
+	cells := func(row []string) []string {
+		out := make([]string, cols)
+		for i := range out {
+			if i < len(row) {
+				out[i] = o.cell(row[i])
+			}
+			if w := StringWidth(out[i]); w > widths[i] {
+				widths[i] = w
+			}
+		}
+		return out
+	}
+	var header []string
+	if len(headers) > 0 {
+		header = cells(headers)
+	}
+	body := make([][]string, len(rows))
+	for i, row := range rows {
+		body[i] = cells(row)
+	}
+
+	b := o.border
+	var out []string
+	out = append(out, o.rule(widths, b.TopLeft, b.Top, orDefault(b.MiddleTop, b.Top), b.TopRight))
+	if header != nil {
+		out = append(out, o.row(header, widths))
+		if o.headerSeparator {
+			out = append(out, o.rule(widths, orDefault(b.MiddleLeft, b.Left), b.Top, orDefault(b.Middle, b.Left), orDefault(b.MiddleRight, b.Right)))
+		}
+	}
+	for _, row := range body {
+		out = append(out, o.row(row, widths))
+	}
+	out = append(out, o.rule(widths, b.BottomLeft, b.Bottom, orDefault(b.MiddleBottom, b.Bottom), b.BottomRight))
+	return strings.Join(out, "\n")
+}
+
+// cell makes the contents of a cell ready to be drawn: on one line and no
+// wider than allowed.
+func (o tableOptions) cell(s string) string {
+	s = strings.Join(SplitLines(s), " ")
+	if o.maxCellWidth <= 0 || StringWidth(s) <= o.maxCellWidth {
+		return s
+	}
+
+	cut := sliceCells(s, 0, o.maxCellWidth-1)
+	if strings.ContainsRune(s, '\x1b') {
+		cut += resetSeq
+	}
+	return cut + "…"
+}
+
+// row draws a line of the table.
+func (o tableOptions) row(cells []string, widths []int) string {
+	var (
+		b   strings.Builder
+		pad = strings.Repeat(" ", o.padding)
+	)
+	b.WriteString(o.border.Left)
+	for i, c := range cells {
+		if i > 0 {
+			b.WriteString(o.border.Left)
+		}
+		b.WriteString(pad)
+		b.WriteString(Pad(c, widths[i], AlignLeft))
+		b.WriteString(pad)
+	}
+	b.WriteString(o.border.Right)
+	return b.String()
+}
+
+// rule draws a horizontal line across the table, with left and right at
+// either end, line across each column and cross where the columns meet.
+func (o tableOptions) rule(widths []int, left, line, cross, right string) string {
+	var b strings.Builder
+	b.WriteString(left)
+	for i, w := range widths {
+		if i > 0 {
+			b.WriteString(cross)
+		}
+		b.WriteString(strings.Repeat(line, w+2*o.padding))
+	}
+	b.WriteString(right)
+	return b.String()
+}
+
+// orDefault returns s, or def if s is empty.
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,71 @@
+package tea
+
+import (
+	"reflect"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TaggedMsg is a message from a command wrapped with Tag. ID is the ID it was
+// tagged with and Msg is the message itself.
+type TaggedMsg struct {
+	ID  interface{}
+	Msg Msg
+}
+
+// Tag wraps the message a command returns in a TaggedMsg with the given ID.
+// It's for models made up of other models, where each child's commands need
+// to find their way back to that child:
+//
+//   func (m parent) update(msg Msg) (parent, Cmd) {
+//       if msg, ok := Untag(msg, "sidebar"); ok {
+//           var cmd Cmd
+//           m.sidebar, cmd = m.sidebar.update(msg)
+//           return m, Tag("sidebar", cmd)
+//       }
+//       ...
+//   }
+//
+// Commands made with Batch are tagged one by one, and special commands such as
+// Quit keep working as they would otherwise. The ID can be any comparable
+// value.
+func Tag(id interface{}, cmd Cmd) Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() Msg {
+		return tagMsg(id, cmd())
+	}
+}
+
+// tagMsg wraps msg in a TaggedMsg with the given ID. Internal messages, such
+// as the one Quit sends, are left as they are.
+func tagMsg(id interface{}, msg Msg) Msg {
+	return mapMsg(msg, func(msg Msg) Msg {
+		if isInternalMsg(msg) {
+			return msg
+		}
+		return TaggedMsg{ID: id, Msg: msg}
+	})
+}
+
+// Untag returns the message wrapped in msg if msg is a TaggedMsg with the given
+// ID. Otherwise it returns false.
+func Untag(msg Msg, id interface{}) (Msg, bool) {
+	t, ok := msg.(TaggedMsg)
+	if !ok || t.ID != id {
+		return nil, false
+	}
+	return t.Msg, true
+}
+
+// isInternalMsg reports whether msg is one of the messages we use internally,
+// such as the one Quit sends, which have to reach the program as they are.
+func isInternalMsg(msg Msg) bool {
+	t := reflect.TypeOf(msg)
+	if t.PkgPath() != reflect.TypeOf(quitMsg{}).PkgPath() {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(t.Name())
+	return !unicode.IsUpper(r)
+}
@@ -10,13 +10,19 @@
 package tea
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"reflect"
 	"runtime/debug"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	te "github.com/muesli/termenv"
-	"golang.org/x/crypto/ssh/terminal"
 )
 
 // Msg represents an action and is usually the result of an IO operation. It's
@@ -32,16 +38,33 @@ type Model interface{}
 // There's almost never a need to use a command to send a message to another
 // part of your program. Instead, it can almost always be done in the update
 // function.
+//
+// The view of the model an update returns is always drawn before the message
+// from the command it returned is handled, however quickly the command
+// finishes. So a loading indicator shown while a command runs is seen, if
+// only for a frame, rather than being skipped over.
 type Cmd func() Msg
 
 // Batch peforms a bunch of commands concurrently with no ordering guarantees
-// about the results.
+// about the results. Nil commands are ignored, so a batch of one command is
+// just that command and a batch of none is nil.
 func Batch(cmds ...Cmd) Cmd {
-	if len(cmds) == 0 {
-		return nil
+	var valid []Cmd
+	for _, cmd := range cmds {
+		if cmd != nil {
+			valid = append(valid, cmd)
+		}
 	}
-	return func() Msg {
-		return batchMsg(cmds)
+
+	switch len(valid) {
+	case 0:
+		return nil
+	case 1:
+		return valid[0]
+	default:
+		return func() Msg {
+			return batchMsg{cmds: valid}
+		}
 	}
 }
 
@@ -57,17 +80,207 @@ type Update func(Msg, Model) (Model, Cmd)
 // after every Update.
 type View func(Model) string
 
+// NoRender can be returned from View to skip rendering for that update,
+// leaving whatever's currently on screen untouched. It's useful for holding
+// off on rendering during transitions or while data is loading.
+const NoRender = "\x00NORENDER"
+
 // Program is a terminal user interface.
 type Program struct {
+	// metrics must be first so its counters are 64-bit aligned.
+	metrics metrics
+
 	init   Init
 	update Update
 	view   View
 
+	// functions handling particular types of message in place of update
+	handlers map[reflect.Type]Update
+
+	// guards update, view and handlers, which can be swapped out while the
+	// program's running
+	funcsMtx sync.RWMutex
+
 	mtx             sync.Mutex
-	output          *os.File // where to send output. this will usually be os.Stdout.
+	terms           termStore   // where terminal devices' state is kept
+	termStates      []termState // original state of the terminal devices we've changed
+	input           io.Reader   // where to read input from. this will usually be os.Stdin.
+	inputReader     inputReader // the input, wrapped so that reads can be cancelled
+	output          io.Writer   // where to send output. this will usually be os.Stdout.
+	mirror          io.Writer   // where to send a copy of the output, if anywhere
+	profile         te.Profile  // the colors the output supports, worked out at startup
 	renderer        *renderer
 	altScreenActive bool
 
+	// whether we've put the cursor keys and keypad in application mode,
+	// having found them in normal mode. guarded by mtx.
+	appCursorKeys bool
+	appKeypad     bool
+
+	// state for the running program
+	cmds         chan dispatch
+	msgs         chan Msg
+	errs         chan error
+	done         chan struct{}
+	model        Model
+	timers       timers
+	requests     requestTable // nil unless we're dropping stale requests
+	currentMsg   Msg          // the message being processed, if any
+	err          error        // why the event loop stopped, if it failed
+	shutdownOnce sync.Once
+	running      uint32 // whether the event loop is running. atomic.
+
+	// whether the event loop is driven by the caller via Tick rather than
+	// by Start
+	manual bool
+
+	// distance between tab stops when rendering
+	tabWidth int
+
+	// whether to write each frame in a single write
+	frameBuffering bool
+
+	// how to prepare the screen for the first frame
+	startup StartupBehavior
+
+	// whether to reset text attributes before clearing the last frame
+	ansiResetOnClear bool
+
+	// whether to send FrameMsgs from the start
+	frameMessages bool
+
+	// called with each frame before it's written
+	frameObserver func(frame string, n int)
+
+	// whether to refuse to render frames with unsafe control characters
+	strictRendering bool
+
+	// whether to pad each line out to the width of the terminal, and to
+	// wrap long lines between words
+	padLines bool
+	softWrap bool
+
+	// how many frames in a row the renderer may drop under load
+	maxDroppedFrames int
+
+	// the largest frame, in bytes and in lines, the renderer will draw
+	// before cutting it short
+	maxFrameBytes int
+	maxFrameLines int
+
+	// incremented each time input is flushed so we can tell which input
+	// messages are stale. atomic.
+	inputEpoch uint32
+
+	// whether the terminal has been released, what to do with input read in
+	// the meantime and any input being held on to
+	inputMtx      sync.Mutex
+	inputReleased bool
+	releasedInput ReleasedInputStrategy
+	heldInput     []inputMsg
+
+	// where to send the line being read by ReadLine, and what's been read
+	// of it so far. guarded by inputMtx.
+	lineDone chan LineMsg
+	line     []byte
+
+	// signalled when input is resumed, or the program finishes, so reading
+	// can carry on. uses inputMtx.
+	inputCond *sync.Cond
+
+	// where the time comes from
+	clock Clock
+
+	// where to describe how each frame is painted, if anywhere
+	renderDebug io.Writer
+
+	// shared with other programs taking turns with the terminal, if any
+	terminalHandle *TerminalHandle
+
+	// device attribute queries made, and the latest one answered or given
+	// up on. only touched from the event loop.
+	daRequested uint64
+	daAnswered  uint64
+
+	// keys which quit the program without being passed to Update
+	quitKeys map[string]struct{}
+
+	// whether to turn on bracketed paste, and how much of a paste to take
+	// and for how long
+	bracketedPaste bool
+	maxPasteSize   int
+	pasteTimeout   time.Duration
+
+	// how many printable characters arriving at once make input look like
+	// a paste, with zero or less meaning we don't guess
+	pasteThreshold int
+
+	// whether to carry on with the previous model when Update returns nil,
+	// rather than failing, and what to tell about it if so
+	keepNilModel   bool
+	nilModelReport func(err error)
+
+	// size of the message queue and what to do with messages from commands
+	// when it's full
+	msgQueueDepth int
+	backpressure  BackpressureStrategy
+
+	// messages from commands waiting for room in the queue when using
+	// BackpressureDropOldest, and whether one is on its way there
+	overflowMtx   sync.Mutex
+	overflow      []Msg
+	overflowReady chan struct{}
+	forwarding    bool
+
+	// messages sent before the program started, to be delivered once it
+	// has, and how many of them we'll hold on to
+	startupMtx       sync.Mutex
+	started          bool
+	startupMsgs      []Msg
+	startupQueueSize int
+
+	// number of goroutines to run commands on. if zero, each command gets
+	// its own goroutine.
+	cmdPoolSize int
+
+	// called when a command takes at least slowCmdThreshold to run
+	slowCmdHook      func(CmdOrigin, time.Duration)
+	slowCmdThreshold time.Duration
+
+	// where to write a crash report if we panic, and how much to put in it
+	crashReportPath  string
+	crashReportLevel CrashReportLevel
+
+	// how many recent frames to keep, and how many bytes of them
+	frameHistory      int
+	frameHistoryBytes int
+
+	// the last terminal size we reported, and whether and how often to poll
+	// for changes rather than relying on signals
+	size               resizeState
+	resizePolling      bool
+	resizePollInterval time.Duration
+
+	// how long shutting down can take before we give up and exit. zero
+	// means no limit.
+	shutdownTimeout time.Duration
+
+	// cancelled when the program shuts down. see CmdWithContext.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// whether we're in accessible mode, and the view to use if so
+	accessible     bool
+	accessibleView View
+
+	// regions the screen is split into, if it is
+	panes []Pane
+
+	// whether to report focus events, and whether to hide the cursor while
+	// the terminal is out of focus
+	reportFocus      bool
+	parkCursorOnBlur bool
+
 	// CatchPanics is incredibly useful for restoring the terminal to a useable
 	// state after a panic occurs. When this is set, Bubble Tea will recover
 	// from panics, print the stack trace, and disable raw mode. This feature
@@ -75,6 +288,24 @@ type Program struct {
 	CatchPanics bool
 }
 
+// defaultStartupQueueSize is how many messages sent before the program starts
+// are held on to by default.
+const defaultStartupQueueSize = 64
+
+// ErrProgramNotRunning is returned when trying to send a message to
+// a program that has already finished.
+var ErrProgramNotRunning = errors.New("program is not running")
+
+// ErrStartupQueueFull is returned when trying to send a message to a program
+// that hasn't started yet and is already holding on to as many messages as
+// it's allowed. See WithStartupQueueSize.
+var ErrStartupQueueFull = errors.New("startup message queue is full")
+
+// ErrNilModel is returned when Init or Update returns a nil Model. The error
+// returned wraps ErrNilModel and says which function returned it and, for
+// Update, the type of the message it was handling. See WithNilModelKept.
+var ErrNilModel = errors.New("nil model")
+
 // Quit is a special command that tells the Bubble Tea program to exit.
 func Quit() Msg {
 	return quitMsg{}
@@ -84,9 +315,97 @@ func Quit() Msg {
 // send a quitMsg with Quit.
 type quitMsg struct{}
 
+// FlushInput is a special command that discards any input which hasn't been
+// processed yet, such as keys the user pressed while the program was busy
+// with something expensive. Partially received escape sequences are dropped
+// too, so input picks up again with a clean slate.
+func FlushInput() Msg {
+	return flushInputMsg{}
+}
+
+// flushInputMsg is an internal message that signals that pending input should
+// be discarded. You can send a flushInputMsg with FlushInput.
+type flushInputMsg struct{}
+
+// inputMsg wraps a message read from the terminal's input so that we can
+// tell if it was read before the input was last flushed.
+type inputMsg struct {
+	msg   Msg
+	epoch uint32
+	read  time.Time // only set when measuring input latency
+}
+
 // batchMsg is the internal message used to perform a bunch of commands. You
 // can send a batchMsg with Batch.
-type batchMsg []Cmd
+type batchMsg struct {
+	cmds   []Cmd
+	origin origin // where the batch came from, filled in when it's run
+}
+
+// DroppedMsgMsg is sent when messages from commands have been dropped because
+// the message queue was full. It's only sent when using BackpressureDrop.
+type DroppedMsgMsg struct {
+	Count int // number of messages dropped since the last DroppedMsgMsg
+}
+
+// BackpressureStrategy determines what happens to a message produced by a
+// command when the message queue is full. See WithMaxMsgQueueDepth.
+type BackpressureStrategy int
+
+// Available backpressure strategies.
+const (
+	// BackpressureBlock makes the command wait until there's room in the
+	// queue. This is the default.
+	BackpressureBlock BackpressureStrategy = iota
+
+	// BackpressureDrop discards the message. A DroppedMsgMsg is sent to
+	// Update later on reporting how many messages were lost.
+	BackpressureDrop
+
+	// BackpressureDropOldest keeps the message and instead discards the
+	// oldest message from a command that's still waiting to be delivered.
+	// Commands never wait, and Update always gets the most recent messages,
+	// which suits sources where only the latest value matters. As with
+	// BackpressureDrop, a DroppedMsgMsg reports how many messages were lost.
+	BackpressureDropOldest
+)
+
+// StartupBehavior determines how the screen is prepared before the program's
+// first frame is rendered. See WithStartupBehavior.
+type StartupBehavior int
+
+// Available startup behaviors.
+const (
+	// StartupInline renders the program from wherever the cursor happens to
+	// be, below anything that's already on screen. This is the default.
+	StartupInline StartupBehavior = iota
+
+	// StartupClearScreen clears the screen and renders the program from the
+	// top.
+	StartupClearScreen
+
+	// StartupAltScreen renders the program in the alternate screen, which
+	// is exited again when the program exits.
+	StartupAltScreen
+
+	// StartupAltScreenClear is like StartupAltScreen, but also clears the
+	// alternate screen first, for terminals which keep whatever was last
+	// drawn there.
+	StartupAltScreenClear
+)
+
+// TerminalInfoMsg is sent to Update once when the program starts. It
+// describes how the program is being presented to the user.
+type TerminalInfoMsg struct {
+	// ReducedMotion is set when the user would rather avoid animation, such
+	// as when the program is running in accessible mode. Programs should
+	// consider disabling spinners and other animations driven by Tick.
+	ReducedMotion bool
+
+	// ColorProfile is the colors the program's output supports. See
+	// Program.ColorProfile.
+	ColorProfile te.Profile
+}
 
 // WindowSizeMsg is used to report on the terminal size. It's sent to Update
 // once initially and then on every terminal resize.
@@ -96,155 +415,767 @@ type WindowSizeMsg struct {
 }
 
 // NewProgram creates a new Program.
-func NewProgram(init Init, update Update, view View) *Program {
-	return &Program{
+func NewProgram(init Init, update Update, view View, opts ...ProgramOption) *Program {
+	p := &Program{
 		init:   init,
 		update: update,
 		view:   view,
 
-		output:      os.Stdout,
-		CatchPanics: true,
+		input:              os.Stdin,
+		output:             os.Stdout,
+		tabWidth:           defaultTabWidth,
+		frameBuffering:     true,
+		resizePollInterval: defaultResizePollInterval,
+		maxPasteSize:       defaultMaxPasteSize,
+		pasteTimeout:       defaultPasteTimeout,
+		startupQueueSize:   defaultStartupQueueSize,
+		maxFrameBytes:      defaultMaxFrameBytes,
+		maxFrameLines:      defaultMaxFrameLines,
+		clock:              systemClock{},
+		terms:              systemTermStore{},
+		CatchPanics:        true,
+	}
+
+	// Accessible mode can be turned on by the user with an environment
+	// variable as well as by the program.
+	if a11y, _ := strconv.ParseBool(os.Getenv("TEA_A11Y")); a11y {
+		p.accessible = true
 	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	// Mirror everything from here on, including anything written before
+	// the program starts.
+	if p.mirror != nil {
+		p.output = newMirrorWriter(p.output, p.mirror, &p.metrics)
+	}
+
+	p.cmds = make(chan dispatch)
+	p.msgs = make(chan Msg, p.msgQueueDepth)
+	p.overflowReady = make(chan struct{}, 1)
+	p.inputCond = sync.NewCond(&p.inputMtx)
+	p.errs = make(chan error)
+	p.done = make(chan struct{})
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+
+	return p
 }
 
-// Start initializes the program.
+// Start initializes the program. Unless the program was created with
+// WithManualDriver, Start blocks until the program exits.
 func (p *Program) Start() error {
-	var (
-		cmds = make(chan Cmd)
-		msgs = make(chan Msg)
-		errs = make(chan error)
-		done = make(chan struct{})
-	)
-
 	if p.CatchPanics {
-		defer func() {
-			if r := recover(); r != nil {
-				p.ExitAltScreen()
-				fmt.Printf("Caught panic:\n\n%s\n\nRestoring terminal...\n\n", r)
-				debug.PrintStack()
-				return
-			}
-		}()
+		defer p.recoverFromPanic()
 	}
 
 	p.renderer = newRenderer(p.output, &p.mtx)
+	p.renderer.metrics = &p.metrics
+	p.renderer.tabWidth = p.tabWidth
+	p.renderer.frameBuffering = p.frameBuffering
+	p.renderer.resetOnClear = p.ansiResetOnClear
+	p.renderer.maxDroppedFrames = p.maxDroppedFrames
+	p.renderer.maxFrameBytes = p.maxFrameBytes
+	p.renderer.maxFrameLines = p.maxFrameLines
+	p.renderer.padLines = p.padLines
+	p.renderer.softWrap = p.softWrap
+	p.renderer.strict = p.strictRendering
+	p.renderer.observer = p.frameObserver
+	p.renderer.frameMsgs = p.frameMessages
+	p.renderer.parkCursorOnBlur = p.parkCursorOnBlur
+	p.renderer.accessible = p.accessible
+	p.renderer.debug = p.renderDebug
+	p.renderer.clock = p.clock
+	if p.frameHistory > 0 && p.frameHistoryBytes > 0 {
+		p.renderer.history = newFrameHistory(p.frameHistory, p.frameHistoryBytes)
+	}
 
-	err := initTerminal()
+	if p.terminalHandle != nil {
+		if err := p.terminalHandle.acquire(p); err != nil {
+			return err
+		}
+	}
+
+	err := p.initTerminal()
 	if err != nil {
+		if p.terminalHandle != nil {
+			p.terminalHandle.release(p)
+		}
 		return err
 	}
-	defer restoreTerminal()
+	if !p.manual {
+		defer p.shutdown()
+	}
+
+	// Set up the terminal and prepare the screen for the first frame
+	modes := p.startupModes()
+	p.mtx.Lock()
+	_, _ = io.WriteString(p.output, modes.enableSeq())
+	if modes.altScreen {
+		p.altScreenActive = true
+		p.renderer.setAltScreen(true)
+	}
+	p.mtx.Unlock()
+
+	// Colors from ColorFg and the like are for this program's output until
+	// it exits
+	p.profile = p.ColorProfile()
+	addColorProgram(p)
 
 	// Initialize program
-	model, initCmd := p.init()
-	if initCmd != nil {
-		go func() {
-			cmds <- initCmd
-		}()
+	var initCmd Cmd
+	p.model, initCmd = p.init()
+	if p.model == nil {
+		// Without a model there's nothing to carry on with.
+		if p.manual {
+			p.shutdown()
+		}
+		return fmt.Errorf("Init returned a %w", ErrNilModel)
 	}
 
-	// Start renderer
-	p.renderer.start()
+	// Start renderer. When we're being driven manually frames are rendered
+	// on each call to Tick instead.
+	if !p.manual {
+		p.renderer.start()
+		go p.forwardFrames()
+	}
 	p.renderer.altScreenActive = p.altScreenActive
 
 	// Render initial view
-	p.renderer.write(p.view(model))
+	p.renderView()
 
 	// Subscribe to user input
+	if p.input != nil {
+		p.inputReader = newInputReader(p.input)
+		go p.readInputLoop()
+	}
+
+	// Let the program know how it's being presented
+	info := TerminalInfoMsg{ReducedMotion: p.accessible, ColorProfile: p.profile}
 	go func() {
-		for {
-			msg, err := readInput(os.Stdin)
-			if err != nil {
-				errs <- err
-			}
-			msgs <- msg
-		}
+		p.msgs <- info
 	}()
 
-	// Get initial terminal size
-	go func() {
-		w, h, err := terminal.GetSize(int(p.output.Fd()))
-		if err != nil {
-			errs <- err
+	// Quit when asked to terminate, just as for a quit key
+	listenForTerminate(p.done, func() {
+		select {
+		case p.msgs <- quitMsg{}:
+		case <-p.done:
 		}
-		msgs <- WindowSizeMsg{w, h}
-	}()
+	})
 
-	// Listen for window resizes
-	go listenForResize(p.output, msgs, errs)
+	if f, ok := p.terminalOutput().(*os.File); ok {
+		// Get initial terminal size
+		go p.checkResize(f)
 
-	// Process commands
-	go func() {
-		for {
-			select {
-			case <-done:
-				return
-			case cmd := <-cmds:
-				if cmd != nil {
-					go func() {
-						msgs <- cmd()
-					}()
-				}
-			}
+		// Listen for window resizes, or poll for them if we can't be told
+		if p.resizePolling || !canListenForResize {
+			go p.pollResize(f, p.resizePollInterval)
+		} else {
+			go listenForResize(p.done, func() { p.checkResize(f) })
 		}
-	}()
+	}
+
+	// Process commands
+	if p.cmdPoolSize > 0 {
+		go p.processCmdsWithPool(p.cmdPoolSize)
+	} else {
+		go p.processCmds()
+	}
+	if p.backpressure == BackpressureDropOldest {
+		go p.forwardOverflow()
+	}
+	if initCmd != nil {
+		p.cmds <- dispatch{cmd: initCmd, origin: originOf(nil), frame: p.renderer.written}
+	}
+
+	// From here on messages go straight to the queue. Anything sent earlier
+	// is delivered before the first message is taken from it.
+	p.startupMtx.Lock()
+	p.started = true
+	p.startupMtx.Unlock()
+	atomic.StoreUint32(&p.running, 1)
+
+	// The caller will drive the event loop from here on.
+	if p.manual {
+		return nil
+	}
 
 	// Handle updates and draw
+	if p.handleStartupMsgs() {
+		return p.err
+	}
 	for {
 		select {
-		case err := <-errs:
-			close(done)
+		case err := <-p.errs:
 			return err
-		case msg := <-msgs:
+		case msg := <-p.msgs:
+			if p.handleMsg(msg) {
+				return p.err
+			}
+		}
+	}
+}
 
-			// Handle quit message
-			if _, ok := msg.(quitMsg); ok {
-				p.renderer.stop()
-				close(done)
-				return nil
+// InjectMsg sends a message to the program's Update function from outside the
+// program, such as from another goroutine. It blocks until the message has
+// been queued.
+//
+// Messages sent before the program has started are held on to and delivered,
+// in the order they were sent, once it has: after the Init command has been
+// dispatched and before any input. If too many messages are waiting,
+// ErrStartupQueueFull is returned and the message is dropped. Once the program
+// has finished, ErrProgramNotRunning is returned rather than blocking forever,
+// which makes it safe to call from goroutines that may outlive the program.
+func (p *Program) InjectMsg(msg Msg) error {
+	if atomic.LoadUint32(&p.running) == 0 {
+		p.startupMtx.Lock()
+		if !p.started {
+			defer p.startupMtx.Unlock()
+			if len(p.startupMsgs) >= p.startupQueueSize {
+				return ErrStartupQueueFull
 			}
+			p.startupMsgs = append(p.startupMsgs, msg)
+			return nil
+		}
+		p.startupMtx.Unlock()
+	}
+
+	// Don't leave it to chance whether a message is accepted by a program
+	// that's finished but has room in its queue.
+	select {
+	case <-p.done:
+		return ErrProgramNotRunning
+	default:
+	}
 
-			// Process batch commands
-			if batchedCmds, ok := msg.(batchMsg); ok {
-				for _, cmd := range batchedCmds {
-					cmds <- cmd
-				}
-				continue
+	select {
+	case p.msgs <- msg:
+		return nil
+	case <-p.done:
+		return ErrProgramNotRunning
+	}
+}
+
+// Send sends a message to the program's Update function. It's like InjectMsg,
+// but messages that can't be delivered are simply dropped.
+func (p *Program) Send(msg Msg) {
+	_ = p.InjectMsg(msg)
+}
+
+// handleStartupMsgs delivers the messages sent before the program started. It
+// returns true if the program should quit.
+func (p *Program) handleStartupMsgs() bool {
+	p.startupMtx.Lock()
+	msgs := p.startupMsgs
+	p.startupMsgs = nil
+	p.startupMtx.Unlock()
+
+	for _, msg := range msgs {
+		if p.handleMsg(msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxUnbufferedTickMsgs is how many messages Tick handles at most when the
+// message queue has no buffer, and there's no telling how many are waiting.
+const maxUnbufferedTickMsgs = 64
+
+// Tick processes the messages waiting to be handled and then renders a frame.
+// It's for use with programs created with WithManualDriver, in which case it
+// should be called regularly from your own event loop after calling Start.
+// Update and View are only ever called from within Tick.
+//
+// Tick does not wait for new messages to arrive, and messages that arrive
+// while it's at work are left for the next call, so a steady stream of them
+// can't keep it from returning. Without a buffer for the message queue, see
+// WithMaxMsgQueueDepth, it handles at most 64 messages a call. It reports done
+// once the program has quit (or failed), at which point the terminal has been
+// restored and Tick should not be called again.
+func (p *Program) Tick() (done bool, err error) {
+	if p.CatchPanics {
+		defer p.recoverFromPanic()
+	}
+
+	if p.handleStartupMsgs() {
+		p.shutdown()
+		return true, p.err
+	}
+
+	n := len(p.msgs)
+	if cap(p.msgs) == 0 {
+		n = maxUnbufferedTickMsgs
+	}
+	for waiting := true; waiting && n > 0; n-- {
+		select {
+		case err := <-p.errs:
+			p.shutdown()
+			return true, err
+		case msg := <-p.msgs:
+			if p.handleMsg(msg) {
+				p.shutdown()
+				return true, p.err
 			}
+		default:
+			waiting = false
+		}
+	}
 
-			// Process internal messages for the renderer
-			p.renderer.handleMessages(msg)
-			var cmd Cmd
-			model, cmd = p.update(msg, model) // run update
-			cmds <- cmd                       // process command (if any)
-			p.renderer.write(p.view(model))   // send view to renderer
+	select {
+	case err := <-p.errs:
+		p.shutdown()
+		return true, err
+	default:
+		p.renderer.flush()
+		return false, nil
+	}
+}
+
+// handleMsg runs a message through the program, updating the model and
+// sending the view to the renderer. It returns true if the program should
+// quit, having set p.err if that's because something went wrong.
+func (p *Program) handleMsg(msg Msg) bool {
+	// Unwrap input, dropping anything that was read before input was last
+	// flushed
+	var read time.Time
+	if in, ok := msg.(inputMsg); ok {
+		if in.epoch != atomic.LoadUint32(&p.inputEpoch) {
+			return false
 		}
+		msg, read = in.msg, in.read
+	}
+
+	// Quit keys are handled here and never make it to Update
+	if k, ok := msg.(KeyMsg); ok && len(p.quitKeys) > 0 {
+		if _, quit := p.quitKeys[k.String()]; quit {
+			return true
+		}
+	}
+
+	switch msg := msg.(type) {
+	// Handle quit message
+	case quitMsg:
+		return true
+
+	// Query the terminal's device attributes, and keep track of whether it
+	// answered in time
+	case requestDeviceAttributesMsg:
+		p.requestDeviceAttributes()
+		return false
+	case DeviceAttributesMsg:
+		p.daAnswered = p.daRequested
+	case deviceAttributesTimeoutMsg:
+		if msg.seq <= p.daAnswered {
+			return false
+		}
+		p.daAnswered = msg.seq
+		return p.handleMsg(DeviceAttributesMsg{TimedOut: true})
+
+	// The terminal's said which modes the cursor keys and keypad are in
+	case modeReportMsg:
+		p.keyModesReported(msg)
+		return false
+
+	// Read a line of text. This blocks until the user's done, so it's run as
+	// a command.
+	case readLineMsg:
+		prompt := msg.prompt
+		p.cmds <- dispatch{cmd: func() Msg { return p.readLine(prompt) }}
+		return false
+
+	// A command was cancelled, so there's nothing to do
+	case cancelledMsg:
+		return false
+
+	// A command panicked. Pick up where it left off.
+	case cmdPanicMsg:
+		panic(msg)
+
+	// Process batch commands
+	case batchMsg:
+		for _, cmd := range msg.cmds {
+			p.cmds <- dispatch{cmd: cmd, origin: msg.origin, frame: p.renderer.written}
+		}
+		return false
+
+	// Flush pending input
+	case flushInputMsg:
+		atomic.AddUint32(&p.inputEpoch, 1)
+		if f, ok := p.input.(*os.File); ok {
+			_ = flushInput(f)
+		}
+		return false
+
+	// Dispatch tagged commands and filter out stale results
+	case requestMsg:
+		if p.requests == nil {
+			p.cmds <- dispatch{cmd: msg.cmd, origin: msg.origin, frame: p.renderer.written}
+			return false
+		}
+		p.requests.issue(msg.id, msg.seq)
+		frame := p.renderer.written
+		p.cmds <- dispatch{
+			cmd: func() Msg {
+				// Finish the command here, so that what's compared and
+				// delivered is its actual result.
+				result := p.resolveCmdMsg(msg.cmd(), frame)
+				return requestResultMsg{id: msg.id, seq: msg.seq, msg: result}
+			},
+			origin: msg.origin,
+			frame:  frame,
+		}
+		return false
+	case requestResultMsg:
+		if !p.requests.deliver(msg.id, msg.seq) {
+			return false
+		}
+		return p.handleMsg(msg.msg)
+
+	// Start and stop timers
+	case startTimerMsg:
+		p.timers.start(msg.id, msg.interval, p.clock, p.msgs)
+		return false
+	case stopTimerMsg:
+		p.timers.stop(msg.id)
+		return false
+	}
+
+	// Process internal messages for the renderer
+	p.renderer.handleMessages(msg)
+
+	// Messages nothing's interested in skip updating and rendering
+	// altogether
+	if update := p.updateFor(msg); update != nil {
+		p.currentMsg = msg
+		start := time.Now()
+		model, cmd := update(msg, p.model) // run update
+		p.metrics.addUpdate(time.Since(start))
+		if model == nil {
+			// Fail now, while we know which message did it, rather than
+			// leave View to trip over it.
+			err := fmt.Errorf("Update returned a %w for %T", ErrNilModel, msg)
+			if !p.keepNilModel {
+				p.err = err
+				return true
+			}
+			if p.nilModelReport != nil {
+				p.nilModelReport(err)
+			}
+			model = p.model
+		}
+		p.model = model
+		p.renderView() // send view to renderer
+		if inputLatencyStamps && !read.IsZero() {
+			p.renderer.stampInput(read)
+		}
+
+		// Process the command, if any. The view goes to the renderer first
+		// so that it can be drawn before the command's message is handled.
+		p.cmds <- dispatch{cmd: cmd, origin: originOf(msg), frame: p.renderer.written}
+		p.currentMsg = nil
+	}
+
+	// Let the program know if we've had to drop any messages
+	if n := atomic.SwapUint64(&p.metrics.unreportedDropped, 0); n > 0 {
+		return p.handleMsg(DroppedMsgMsg{Count: int(n)})
+	}
+
+	return false
+}
+
+// currentView renders the model, using the accessible view if we're in
+// accessible mode and the program provided one.
+func (p *Program) currentView() string {
+	if p.accessible && p.accessibleView != nil {
+		return p.accessibleView(p.model)
+	}
+
+	p.funcsMtx.RLock()
+	view := p.view
+	p.funcsMtx.RUnlock()
+	return view(p.model)
+}
+
+// SetView replaces the program's View function, such as to show a loading
+// screen in place of the whole UI during a transition. The new function is
+// used the next time the view is rendered, which happens after the next
+// message is processed. In accessible mode a view set with
+// WithAccessibleView still takes precedence.
+//
+// SetView is safe to call from any goroutine, including from within Update.
+func (p *Program) SetView(view View) {
+	p.funcsMtx.Lock()
+	defer p.funcsMtx.Unlock()
+	p.view = view
+}
+
+// SetUpdate replaces the program's Update function. The new function is used
+// for the next message processed; if SetUpdate is called from within Update,
+// that's the message after the current one. Messages with a handler of their
+// own, see WithHandler, aren't affected.
+//
+// SetUpdate is safe to call from any goroutine, including from within Update.
+func (p *Program) SetUpdate(update Update) {
+	p.funcsMtx.Lock()
+	defer p.funcsMtx.Unlock()
+	p.update = update
+}
+
+// processCmds runs each command it receives in its own goroutine.
+func (p *Program) processCmds() {
+	for {
+		select {
+		case <-p.done:
+			return
+		case d := <-p.cmds:
+			if d.cmd != nil {
+				go p.runCmd(d)
+			}
+		}
+	}
+}
+
+// processCmdsWithPool runs the commands it receives on a fixed number of
+// worker goroutines. Commands queue up while all the workers are busy. The
+// queue is unbounded so the event loop never has to wait on a worker, which
+// could in turn be waiting on the event loop.
+func (p *Program) processCmdsWithPool(size int) {
+	work := make(chan dispatch)
+	defer close(work)
+
+	for i := 0; i < size; i++ {
+		go func() {
+			for d := range work {
+				p.runCmd(d)
+			}
+		}()
+	}
+
+	var queue []dispatch
+	for {
+		// Only offer work when we have some
+		var (
+			next dispatch
+			out  chan dispatch
+		)
+		if len(queue) > 0 {
+			next, out = queue[0], work
+		}
+
+		select {
+		case <-p.done:
+			return
+		case d := <-p.cmds:
+			if d.cmd != nil {
+				queue = append(queue, d)
+			}
+		case out <- next:
+			queue = queue[1:]
+		}
+	}
+}
+
+// runCmd runs a command and sends its message to the event loop.
+func (p *Program) runCmd(d dispatch) {
+	if p.CatchPanics {
+		defer p.recoverFromCmdPanic(d.origin)
+	}
+
+	start := time.Now()
+	msg := p.resolveCmdMsg(d.cmd(), d.frame)
+	if elapsed := time.Since(start); p.slowCmdHook != nil && elapsed >= p.slowCmdThreshold {
+		p.slowCmdHook(d.origin.export(), elapsed)
+	}
+
+	// Make sure the view from the update that issued the command is drawn
+	// before the command's message changes it
+	if msg != nil && d.frame > 0 {
+		p.renderer.holdFrame(d.frame)
+	}
+
+	// Commands that produce more commands pass their origin along
+	switch m := msg.(type) {
+	case batchMsg:
+		m.origin = d.origin
+		msg = m
+	case requestMsg:
+		m.origin = d.origin
+		msg = m
+	}
+
+	p.sendCmdMsg(msg)
+}
+
+// resolveCmdMsg finishes running a command whose message is one of those
+// carrying work that needs the program, such as its context or clock, and
+// returns the message that work produces. Any other message is returned as
+// it is. frame is the frame drawn by the update that issued the command.
+func (p *Program) resolveCmdMsg(msg Msg, frame uint64) Msg {
+	switch fn := msg.(type) {
+	case contextCmdMsg:
+		return p.resolveCmdMsg(fn(p.ctx), frame)
+	case clockCmdMsg:
+		return p.resolveCmdMsg(fn(p.ctx, p.clock), frame)
+	case frameTickMsg:
+		return p.resolveCmdMsg(p.frameTick(fn, frame), frame)
+	}
+	return msg
+}
+
+// sendCmdMsg queues a message produced by a command, applying the
+// backpressure strategy if the queue is full.
+func (p *Program) sendCmdMsg(msg Msg) {
+	if p.backpressure == BackpressureDropOldest {
+		p.queueOverflow(msg)
+		return
+	}
+	if p.backpressure != BackpressureDrop {
+		select {
+		case p.msgs <- msg:
+		case <-p.done: // nobody's listening anymore
+		}
+		return
+	}
+
+	select {
+	case p.msgs <- msg:
+	case <-p.done:
+	default:
+		p.metrics.addDroppedMsg()
+	}
+}
+
+// shutdown stops the renderer and restores the terminal. It's safe to call
+// more than once.
+func (p *Program) shutdown() {
+	p.shutdownOnce.Do(func() {
+		// Don't let a hung terminal keep the process around forever
+		if p.shutdownTimeout > 0 {
+			t := time.AfterFunc(p.shutdownTimeout, func() {
+				fmt.Fprintf(os.Stderr, "bubbletea: shutdown took longer than %s, exiting\n", p.shutdownTimeout)
+				os.Exit(1)
+			})
+			defer t.Stop()
+		}
+
+		atomic.StoreUint32(&p.running, 0)
+		p.cancel()
+		p.timers.stopAll()
+		p.requests = nil
+		p.renderer.stop()
+		close(p.done)
+
+		// Undo everything we did to the terminal at startup, and leave the
+		// alternate screen if we're in it, however we got there
+		p.mtx.Lock()
+		modes := p.startupModes()
+		modes.altScreen = p.altScreenActive
+		_, _ = io.WriteString(p.output, modes.disableSeq())
+		if modes.altScreen {
+			p.altScreenActive = false
+			p.renderer.setAltScreen(false)
+		}
+		p.mtx.Unlock()
+		_ = p.restoreTerminal()
+		if m, ok := p.output.(*mirrorWriter); ok {
+			m.close()
+		}
+		removeColorProgram(p)
+
+		// Let the input reader go, if it's waiting for the terminal to be
+		// restored, and stop it taking any more input, unless there's
+		// another program to pass it on to
+		p.inputMtx.Lock()
+		p.inputCond.Broadcast()
+		if p.inputReader != nil && p.terminalHandle == nil {
+			p.inputReader.cancel()
+		}
+		p.inputMtx.Unlock()
+
+		// Hand the terminal back to whoever yielded it to us
+		if p.terminalHandle != nil {
+			p.terminalHandle.release(p)
+		}
+	})
+}
+
+// recoverFromPanic recovers from a panic, prints the stack trace, and restores
+// the terminal to a usable state. It must be deferred.
+func (p *Program) recoverFromPanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	// If the panic came from a command, report it as it happened there.
+	trace := debug.Stack()
+	var cmdOrigin *CmdOrigin
+	if cp, ok := r.(cmdPanicMsg); ok {
+		r, trace = cp.value, cp.stack
+		o := cp.origin.export()
+		cmdOrigin = &o
+	}
+
+	p.writeCrashReport(r, trace, cmdOrigin)
+	p.shutdown()
+	p.ExitAltScreen()
+	if cmdOrigin != nil {
+		fmt.Printf("Caught panic in command from %s:\n\n%s\n\nRestoring terminal...\n\n", cmdOrigin, r)
+	} else {
+		fmt.Printf("Caught panic:\n\n%s\n\nRestoring terminal...\n\n", r)
+	}
+	_, _ = os.Stderr.Write(trace)
+}
+
+// finished reports whether the program has shut down.
+func (p *Program) finished() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
 	}
 }
 
 // EnterAltScreen enters the alternate screen buffer, which consumes the entire
 // terminal window. ExitAltScreen will return the terminal to its former state.
+// It can be called before or after Start, and does nothing if the alternate
+// screen is already active. If the program is still in the alternate screen
+// when it exits, it's exited for you, and once it's exited this does nothing.
+//
+// Prefer WithStartupBehavior for programs that use the alternate screen from
+// start to finish.
 func (p *Program) EnterAltScreen() {
 	p.mtx.Lock()
 	defer p.mtx.Unlock()
+	if p.altScreenActive || p.finished() {
+		return
+	}
 	fmt.Fprintf(p.output, te.CSI+te.AltScreenSeq)
 	moveCursor(p.output, 0, 0)
 
 	p.altScreenActive = true
 	if p.renderer != nil {
-		p.renderer.altScreenActive = p.altScreenActive
+		p.renderer.setAltScreen(p.altScreenActive)
 	}
 }
 
-// ExitAltScreen exits the alternate screen buffer.
+// ExitAltScreen exits the alternate screen buffer. It does nothing if the
+// alternate screen isn't active.
 func (p *Program) ExitAltScreen() {
 	p.mtx.Lock()
 	defer p.mtx.Unlock()
+	if !p.altScreenActive {
+		return
+	}
 	fmt.Fprintf(p.output, te.CSI+te.ExitAltScreenSeq)
 
 	p.altScreenActive = false
 	if p.renderer != nil {
-		p.renderer.altScreenActive = p.altScreenActive
+		p.renderer.setAltScreen(p.altScreenActive)
 	}
 }
 
@@ -0,0 +1,585 @@
+package tea
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testTimeout is how long tests wait for something to happen before giving
+// up on it.
+const testTimeout = 5 * time.Second
+
+// runMsg has a test program run a command.
+type runMsg struct{ cmd Cmd }
+
+// testProgram is a program for tests, with no input, drawing to a
+// VirtualTerminal and getting the time from a TestClock. Every message its
+// Update gets, other than runMsg and TerminalInfoMsg, is passed on to msgs.
+type testProgram struct {
+	*Program
+	t     *testing.T
+	term  *VirtualTerminal
+	clock *TestClock
+	msgs  chan Msg
+	errc  chan error
+}
+
+// startTestProgram starts a test program. Stop it with stop once the test is
+// done with it.
+func startTestProgram(t *testing.T, opts ...ProgramOption) *testProgram {
+	t.Helper()
+	tp := &testProgram{
+		t:     t,
+		term:  NewVirtualTerminal(80, 24),
+		clock: NewTestClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+		msgs:  make(chan Msg, 100),
+		errc:  make(chan error, 1),
+	}
+	opts = append([]ProgramOption{
+		WithInput(nil),
+		WithOutput(tp.term),
+		WithClock(tp.clock),
+	}, opts...)
+
+	tp.Program = NewProgram(
+		func() (Model, Cmd) { return 0, nil },
+		func(msg Msg, m Model) (Model, Cmd) {
+			switch msg := msg.(type) {
+			case runMsg:
+				return m, msg.cmd
+			case TerminalInfoMsg:
+			default:
+				tp.msgs <- msg
+			}
+			return m, nil
+		},
+		func(Model) string { return "test" },
+		opts...,
+	)
+	go func() {
+		tp.errc <- tp.Start()
+	}()
+	return tp
+}
+
+// stop quits the program and waits for it to finish.
+func (tp *testProgram) stop() {
+	tp.t.Helper()
+	tp.Send(runMsg{Quit})
+	select {
+	case <-tp.errc:
+	case <-time.After(testTimeout):
+		tp.t.Error("program didn't quit")
+	}
+}
+
+// run has the program run cmd.
+func (tp *testProgram) run(cmd Cmd) {
+	tp.t.Helper()
+	if err := tp.InjectMsg(runMsg{cmd}); err != nil {
+		tp.t.Fatal(err)
+	}
+}
+
+// advance waits for n timers and tickers to be waiting on the clock, on top
+// of the renderer's ticker, and then moves the clock along by d.
+func (tp *testProgram) advance(n int, d time.Duration) {
+	tp.t.Helper()
+	waiting := make(chan struct{})
+	go func() {
+		tp.clock.BlockUntil(n + 1)
+		close(waiting)
+	}()
+	select {
+	case <-waiting:
+	case <-time.After(testTimeout):
+		tp.t.Fatalf("timed out waiting for %d timers", n)
+	}
+	tp.clock.Advance(d)
+}
+
+// expect fails the test unless the next message Update gets is want.
+func (tp *testProgram) expect(want Msg) {
+	tp.t.Helper()
+	select {
+	case got := <-tp.msgs:
+		if got != want {
+			tp.t.Fatalf("got %T %#v, want %T %#v", got, got, want, want)
+		}
+	case <-time.After(testTimeout):
+		tp.t.Fatalf("timed out waiting for %#v", want)
+	}
+}
+
+// expectNone fails the test if Update gets a message within d.
+func (tp *testProgram) expectNone(d time.Duration) {
+	tp.t.Helper()
+	select {
+	case got := <-tp.msgs:
+		tp.t.Fatalf("got unexpected %T %#v", got, got)
+	case <-time.After(d):
+	}
+}
+
+// expectScreen fails the test unless the terminal comes to show want, with
+// trailing blank lines ignored.
+func (tp *testProgram) expectScreen(want string) {
+	tp.t.Helper()
+	var got string
+	for deadline := time.Now().Add(testTimeout); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if got = strings.TrimRight(tp.term.String(), "\n"); got == want {
+			return
+		}
+	}
+	tp.t.Fatalf("screen shows\n%s\nwant\n%s", got, want)
+}
+
+// draw has the program render with view, once the message it's sent to do
+// so has been handled.
+func (tp *testProgram) draw(view string) {
+	tp.t.Helper()
+	tp.SetView(func(Model) string { return view })
+	tp.run(func() Msg { return DrawMsg{} })
+	tp.expect(DrawMsg{})
+	tp.clock.Advance(defaultFramerate)
+}
+
+// DrawMsg is sent by draw to have the program render. It's exported so
+// that it isn't taken for one of the package's internal messages.
+type DrawMsg struct{}
+
+func TestNoQuitKeys(t *testing.T) {
+	tp := startTestProgram(t)
+
+	// With no quit keys, ctrl+c is just another key, and the program only
+	// quits when it says so.
+	tp.Send(KeyMsg{Type: KeyCtrlC})
+	tp.expect(KeyMsg{Type: KeyCtrlC})
+	tp.stop()
+}
+
+func TestQuitKeys(t *testing.T) {
+	tp := startTestProgram(t, WithQuitKeys("ctrl+c", "q"))
+
+	tp.Send(KeyMsg{Type: KeyCtrlD})
+	tp.expect(KeyMsg{Type: KeyCtrlD})
+	tp.Send(KeyMsg{Type: KeyRune, Rune: 'q'})
+	select {
+	case <-tp.errc:
+	case <-time.After(testTimeout):
+		t.Fatal("program didn't quit")
+	}
+	// The quit key never made it to Update.
+	tp.expectNone(10 * time.Millisecond)
+}
+
+func TestInitNilModel(t *testing.T) {
+	p := NewProgram(
+		func() (Model, Cmd) { return nil, nil },
+		func(msg Msg, m Model) (Model, Cmd) { return m, nil },
+		func(Model) string { return "" },
+		WithInput(nil),
+		WithOutput(NewVirtualTerminal(80, 24)),
+		WithClock(NewTestClock(time.Now())),
+	)
+	err := p.Start()
+	if !errors.Is(err, ErrNilModel) || err.Error() != "Init returned a nil model" {
+		t.Errorf("got %v, want Init's nil model", err)
+	}
+}
+
+func TestUpdateNilModel(t *testing.T) {
+	tp := startTestProgram(t)
+	tp.SetUpdate(func(msg Msg, m Model) (Model, Cmd) {
+		if _, ok := msg.(StormMsg); ok {
+			return nil, nil
+		}
+		return m, nil
+	})
+	tp.Send(StormMsg{})
+	select {
+	case err := <-tp.errc:
+		if !errors.Is(err, ErrNilModel) || err.Error() != "Update returned a nil model for tea.StormMsg" {
+			t.Errorf("got %v, want Update's nil model for StormMsg", err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("program didn't quit")
+	}
+}
+
+func TestUpdateNilModelKept(t *testing.T) {
+	reported := make(chan error, 1)
+	tp := startTestProgram(t, WithNilModelKept(func(err error) { reported <- err }))
+	defer tp.stop()
+	tp.SetUpdate(func(msg Msg, m Model) (Model, Cmd) {
+		switch msg := msg.(type) {
+		case runMsg:
+			return m, msg.cmd
+		case StormMsg:
+			return nil, func() Msg { return DrawMsg{} }
+		case DrawMsg:
+			tp.msgs <- m
+		}
+		return m, nil
+	})
+	tp.Send(StormMsg{})
+	select {
+	case err := <-reported:
+		if !errors.Is(err, ErrNilModel) {
+			t.Errorf("reported %v, want Update's nil model", err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("nil model wasn't reported")
+	}
+
+	// The previous model's kept, and the command Update returned along with
+	// the nil one is run.
+	tp.expect(0)
+}
+
+// StepMsg and StepDoneMsg are a request and its result, for tests of command
+// ordering.
+type (
+	StepMsg     int
+	StepDoneMsg int
+)
+
+func TestInstantCmdAfterFrame(t *testing.T) {
+	// The model counts updates, and each result of an instant command says
+	// which update asked for it.
+	const steps = 200
+	var (
+		mtx       sync.Mutex
+		drawn     []int           // the model of each frame drawn, in order
+		requested = map[int]int{} // result: update that asked for it
+		applied   = map[int]int{} // result: update that applied it
+	)
+	p := NewProgram(
+		func() (Model, Cmd) { return 0, nil },
+		func(msg Msg, m Model) (Model, Cmd) {
+			n := m.(int) + 1
+			switch msg := msg.(type) {
+			case StepMsg:
+				requested[int(msg)] = n
+				return n, func() Msg { return StepDoneMsg(msg) }
+			case StepDoneMsg:
+				applied[int(msg)] = n
+				if msg == steps-1 {
+					return n, Quit
+				}
+			}
+			return n, nil
+		},
+		func(m Model) string { return strconv.Itoa(m.(int)) },
+		WithInput(nil),
+		WithOutput(NewVirtualTerminal(80, 24)),
+		WithFrameObserver(func(frame string, _ int) {
+			n, _ := strconv.Atoi(frame)
+			mtx.Lock()
+			drawn = append(drawn, n)
+			mtx.Unlock()
+		}),
+	)
+	go func() {
+		for i := 0; i < steps; i++ {
+			for p.InjectMsg(StepMsg(i)) == ErrStartupQueueFull {
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}()
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Before any frame with a result applied is drawn, the update that
+	// asked for it, or a later one, has been drawn.
+	mtx.Lock()
+	defer mtx.Unlock()
+	for i := 0; i < steps; i++ {
+		req, app := requested[i], applied[i]
+		for _, n := range drawn {
+			if n >= app {
+				t.Errorf("result %d, asked for by update %d, drawn in update %d before it", i, req, n)
+				break
+			}
+			if n >= req {
+				break
+			}
+		}
+	}
+}
+
+func TestSendBeforeStart(t *testing.T) {
+	var (
+		p   *Program
+		got []Msg
+	)
+	p = NewProgram(
+		func() (Model, Cmd) {
+			// Sent during startup, before the program's running.
+			if err := p.InjectMsg(StepMsg(3)); err != nil {
+				t.Errorf("sending during startup: %v", err)
+			}
+			return 0, func() Msg { return StepMsg(4) }
+		},
+		func(msg Msg, m Model) (Model, Cmd) {
+			if msg, ok := msg.(StepMsg); ok {
+				got = append(got, msg)
+				if msg == 4 {
+					return m, Quit
+				}
+			}
+			return m, nil
+		},
+		func(Model) string { return "" },
+		WithInput(nil),
+		WithOutput(NewVirtualTerminal(80, 24)),
+		WithStartupQueueSize(4),
+	)
+
+	// Messages sent before Start are held on to, up to a point.
+	for i := 0; i < 3; i++ {
+		if err := p.InjectMsg(StepMsg(i)); err != nil {
+			t.Fatalf("sending %d before Start: %v", i, err)
+		}
+	}
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// They're delivered in order, ahead of the Init command's message.
+	want := []Msg{StepMsg(0), StepMsg(1), StepMsg(2), StepMsg(3), StepMsg(4)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// Once the program's finished, messages aren't accepted.
+	if err := p.InjectMsg(StepMsg(5)); err != ErrProgramNotRunning {
+		t.Errorf("sending after quitting: got %v, want ErrProgramNotRunning", err)
+	}
+	p.Send(StepMsg(6))
+}
+
+func TestSendBeforeStartQueueFull(t *testing.T) {
+	p := NewProgram(
+		func() (Model, Cmd) { return 0, nil },
+		func(msg Msg, m Model) (Model, Cmd) { return m, nil },
+		func(Model) string { return "" },
+		WithStartupQueueSize(2),
+	)
+	for i := 0; i < 2; i++ {
+		if err := p.InjectMsg(StepMsg(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.InjectMsg(StepMsg(2)); err != ErrStartupQueueFull {
+		t.Errorf("got %v, want ErrStartupQueueFull", err)
+	}
+}
+
+// recordingTerminal is a VirtualTerminal that keeps a copy of everything
+// written to it.
+type recordingTerminal struct {
+	*VirtualTerminal
+	mtx sync.Mutex
+	out strings.Builder
+}
+
+func newRecordingTerminal(width, height int) *recordingTerminal {
+	return &recordingTerminal{VirtualTerminal: NewVirtualTerminal(width, height)}
+}
+
+func (t *recordingTerminal) Write(b []byte) (int, error) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.out.Write(b)
+	return t.VirtualTerminal.Write(b)
+}
+
+// take returns what's been written since it was last called.
+func (t *recordingTerminal) take() string {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	s := t.out.String()
+	t.out.Reset()
+	return s
+}
+
+// newStepProgram returns a program drawing "hi" to term, which once it's
+// started calls each of steps in turn from a command and then quits.
+func newStepProgram(term *recordingTerminal, steps ...func()) *Program {
+	p := NewProgram(
+		func() (Model, Cmd) { return 0, nil },
+		func(msg Msg, m Model) (Model, Cmd) {
+			var i int
+			switch msg := msg.(type) {
+			case WindowSizeMsg:
+			case StepMsg:
+				i = int(msg)
+			default:
+				return m, nil
+			}
+			if i == len(steps) {
+				return m, Quit
+			}
+			return m, func() Msg {
+				steps[i]()
+				return StepMsg(i + 1)
+			}
+		},
+		func(Model) string { return "hi" },
+		WithInput(nil),
+		WithOutput(term),
+		WithClock(NewTestClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))),
+	)
+
+	// The terminal's size is known from the start, so that every frame's
+	// drawn to fit it.
+	width, height := term.Size()
+	p.Send(WindowSizeMsg{Width: width, Height: height})
+	return p
+}
+
+// checkTerm fails the test unless term's had want written to it since it was
+// last checked, and its screen's as expected.
+func checkTerm(t *testing.T, term *recordingTerminal, want string, altScreen bool, screen ...string) {
+	t.Helper()
+	if got := term.take(); got != want {
+		t.Errorf("wrote %q, want %q", got, want)
+	}
+	if term.AltScreen() != altScreen {
+		t.Errorf("in the alternate screen: %v, want %v", term.AltScreen(), altScreen)
+	}
+	for len(screen) < 5 {
+		screen = append(screen, "")
+	}
+	if got, want := term.String(), strings.Join(screen, "\n"); got != want {
+		t.Errorf("screen\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestAltScreenBeforeStart(t *testing.T) {
+	term := newRecordingTerminal(80, 5)
+	p := newStepProgram(term)
+
+	// Entering it again does nothing.
+	p.EnterAltScreen()
+	p.EnterAltScreen()
+	checkTerm(t, term, "\x1b[?1049h\x1b[0;0H", true)
+
+	// The program's drawn there, and it's left on the way out.
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	checkTerm(t, term, "\x1b[?25lhi\x1b[1;0H\x1b[?25h\x1b[?1049l", false)
+}
+
+func TestAltScreenWhileRunning(t *testing.T) {
+	term := newRecordingTerminal(80, 5)
+	var p *Program
+	p = newStepProgram(term,
+		func() {
+			checkTerm(t, term, "\x1b[?25l", false)
+			p.EnterAltScreen()
+			p.EnterAltScreen()
+			checkTerm(t, term, "\x1b[?1049h\x1b[0;0H", true)
+		},
+		func() {
+			// The frame drawn in the meantime went to the alternate
+			// screen, not over what's on the main one.
+			p.ExitAltScreen()
+			p.ExitAltScreen()
+			checkTerm(t, term, "hi\x1b[1;0H\x1b[?1049l", false)
+		},
+		func() {
+			// The next frame's drawn in full on the main screen. This
+			// time the alternate screen's left for the program to exit.
+			p.EnterAltScreen()
+			checkTerm(t, term, "hi\x1b[80D\x1b[?1049h\x1b[0;0H", true)
+		},
+	)
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	checkTerm(t, term, "hi\x1b[1;0H\x1b[?25h\x1b[?1049l", false, "hi")
+}
+
+func TestAltScreenAfterQuitting(t *testing.T) {
+	term := newRecordingTerminal(80, 5)
+	p := newStepProgram(term)
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	checkTerm(t, term, "\x1b[?25lhi\x1b[80D\r\n\x1b[?25h", false, "hi")
+
+	// The terminal's been put back as it was, and it stays that way.
+	p.EnterAltScreen()
+	p.ExitAltScreen()
+	p.EnterAltScreen()
+	checkTerm(t, term, "", false, "hi")
+}
+
+func TestTickReturns(t *testing.T) {
+	// A negative queue depth is the same as none.
+	for _, depth := range []int{0, 16, -1} {
+		// Each message is answered with another, straight away, so there's
+		// always one on its way.
+		var updates int
+		again := func() Msg { return StepMsg(0) }
+		p := NewProgram(
+			func() (Model, Cmd) { return 0, again },
+			func(msg Msg, m Model) (Model, Cmd) {
+				if _, ok := msg.(StepMsg); ok {
+					updates++
+					return m, again
+				}
+				return m, nil
+			},
+			func(Model) string { return "" },
+			WithManualDriver(),
+			WithInput(nil),
+			WithOutput(NewVirtualTerminal(80, 24)),
+			WithMaxMsgQueueDepth(depth),
+		)
+		if err := p.Start(); err != nil {
+			t.Fatal(err)
+		}
+
+		// tick calls Tick, failing the test if it doesn't return.
+		tick := func() bool {
+			t.Helper()
+			result := make(chan bool, 1)
+			go func() {
+				done, _ := p.Tick()
+				result <- done
+			}()
+			select {
+			case done := <-result:
+				return done
+			case <-time.After(testTimeout):
+				t.Fatalf("queue depth %d: Tick didn't return", depth)
+				return false
+			}
+		}
+
+		// Messages still get handled, a call at a time, as when Tick's called
+		// once a frame.
+		deadline := time.Now().Add(testTimeout)
+		for updates < 20 && time.Now().Before(deadline) {
+			tick()
+			time.Sleep(time.Millisecond)
+		}
+		if updates < 20 {
+			t.Errorf("queue depth %d: only %d updates", depth, updates)
+		}
+
+		go p.Send(Quit())
+		for !tick() {
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package tea
+
+import (
+	"sync"
+	"time"
+)
+
+// TimerTickMsg is sent each time a timer started with StartTimer ticks.
+type TimerTickMsg struct {
+	ID   string
+	Time time.Time
+}
+
+// StartTimer is a command that starts a timer which sends a TimerTickMsg with
+// the given ID at every interval until it's stopped with StopTimer or the
+// program exits. Unlike Tick and Every, the timer keeps running on its own, so
+// there's no need to return a new command on each tick.
+//
+// Starting a timer with the ID of one that's already running replaces it with
+// one at the new interval. An interval that isn't positive stops the timer.
+//
+//   case KeyMsg:
+//       return m, StartTimer("spinner", time.Second/10)
+//
+//   case TimerTickMsg:
+//       if msg.ID == "spinner" {
+//           m.frame++
+//       }
+func StartTimer(id string, interval time.Duration) Cmd {
+	return func() Msg {
+		return startTimerMsg{id: id, interval: interval}
+	}
+}
+
+// StopTimer is a command that stops the timer with the given ID. Stopping a
+// timer that isn't running does nothing.
+func StopTimer(id string) Cmd {
+	return func() Msg {
+		return stopTimerMsg{id: id}
+	}
+}
+
+// startTimerMsg is an internal message that starts a timer. You can send
+// a startTimerMsg with StartTimer.
+type startTimerMsg struct {
+	id       string
+	interval time.Duration
+}
+
+// stopTimerMsg is an internal message that stops a timer. You can send
+// a stopTimerMsg with StopTimer.
+type stopTimerMsg struct {
+	id string
+}
+
+// timers manages the timers started with StartTimer. It's only used from the
+// event loop.
+type timers struct {
+	active map[string]*timer // timers by ID
+	wg     sync.WaitGroup
+}
+
+// timer is a running timer. Its ticker is started and stopped from the event
+// loop, so that a timer's gone from the clock as soon as it's been stopped.
+type timer struct {
+	ticker Ticker
+	stop   chan struct{}
+}
+
+// start starts a timer, replacing any timer with the same ID.
+func (t *timers) start(id string, interval time.Duration, clock Clock, msgs chan<- Msg) {
+	t.stop(id)
+	if interval <= 0 {
+		return
+	}
+
+	if t.active == nil {
+		t.active = make(map[string]*timer)
+	}
+	tm := &timer{ticker: clock.NewTicker(interval), stop: make(chan struct{})}
+	t.active[id] = tm
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+
+		for {
+			select {
+			case <-tm.stop:
+				return
+			case now := <-tm.ticker.C():
+				// Don't deliver a tick that came in as the timer was
+				// being stopped
+				select {
+				case <-tm.stop:
+					return
+				default:
+				}
+				select {
+				case msgs <- TimerTickMsg{ID: id, Time: now}:
+				case <-tm.stop:
+					return
+				}
+			}
+		}
+	}()
+}
+
+// stop stops the timer with the given ID, if it's running.
+func (t *timers) stop(id string) {
+	if tm, ok := t.active[id]; ok {
+		tm.ticker.Stop()
+		close(tm.stop)
+		delete(t.active, id)
+	}
+}
+
+// stopAll stops all timers and waits for them to finish up.
+func (t *timers) stopAll() {
+	for id := range t.active {
+		t.stop(id)
+	}
+	t.wg.Wait()
+}
@@ -0,0 +1,115 @@
+package tea
+
+import (
+	"bytes"
+	"reflect"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+)
+
+// waitForTickers waits until the tickers on the program's clock, apart from
+// the renderer's, are ones at the given intervals.
+func (tp *testProgram) waitForTickers(want ...time.Duration) {
+	tp.t.Helper()
+	sort.Slice(want, func(i, j int) bool { return want[i] < want[j] })
+	var got []time.Duration
+	for deadline := time.Now().Add(testTimeout); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		got = got[:0]
+		renderer := false
+		tp.clock.mtx.Lock()
+		for _, w := range tp.clock.waiting {
+			if w.period == tp.renderer.framerate && !renderer {
+				renderer = true
+				continue
+			}
+			got = append(got, w.period)
+		}
+		tp.clock.mtx.Unlock()
+		sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+		if len(got) == len(want) && (len(got) == 0 || reflect.DeepEqual(got, want)) {
+			return
+		}
+	}
+	tp.t.Fatalf("tickers at %v, want %v", got, want)
+}
+
+func TestTimers(t *testing.T) {
+	tp := startTestProgram(t)
+	defer tp.stop()
+	start := tp.clock.Now()
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+
+	tp.run(StartTimer("a", time.Second))
+	tp.run(StartTimer("b", 1500*time.Millisecond))
+	tp.advance(2, time.Second)
+	tp.expect(TimerTickMsg{ID: "a", Time: at(time.Second)})
+	tp.advance(2, 500*time.Millisecond)
+	tp.expect(TimerTickMsg{ID: "b", Time: at(1500 * time.Millisecond)})
+	tp.advance(2, 500*time.Millisecond)
+	tp.expect(TimerTickMsg{ID: "a", Time: at(2 * time.Second)})
+
+	// Starting a timer again changes its interval, rather than adding
+	// another.
+	tp.run(StopTimer("b"))
+	tp.waitForTickers(time.Second)
+	tp.run(StartTimer("a", 2*time.Second))
+	tp.waitForTickers(2 * time.Second)
+	tp.advance(1, time.Second)
+	tp.expectNone(10 * time.Millisecond)
+	tp.advance(1, time.Second)
+	tp.expect(TimerTickMsg{ID: "a", Time: at(4 * time.Second)})
+	tp.expectNone(10 * time.Millisecond)
+
+	// Stopped timers don't tick, and stopping them again does nothing.
+	tp.run(StopTimer("a"))
+	tp.waitForTickers()
+	tp.run(StopTimer("a"))
+	tp.run(StartTimer("c", 0))
+	tp.advance(0, 10*time.Second)
+	tp.expectNone(10 * time.Millisecond)
+}
+
+// timerGoroutines returns the stacks of the goroutines running timers.
+func timerGoroutines() []string {
+	buf := make([]byte, 1<<20)
+	buf = buf[:runtime.Stack(buf, true)]
+	var stacks []string
+	for _, g := range bytes.Split(buf, []byte("\n\n")) {
+		if bytes.Contains(g, []byte("(*timers).start")) {
+			stacks = append(stacks, string(g))
+		}
+	}
+	return stacks
+}
+
+func TestTimersStoppedOnExit(t *testing.T) {
+	tp := startTestProgram(t)
+	for _, id := range []string{"a", "b", "c"} {
+		tp.run(StartTimer(id, time.Second))
+	}
+	tp.advance(3, time.Second)
+	for i := 0; i < 3; i++ {
+		if _, ok := (<-tp.msgs).(TimerTickMsg); !ok {
+			t.Fatal("timer didn't tick")
+		}
+	}
+	if got := len(timerGoroutines()); got != 3 {
+		t.Errorf("%d timers running, want 3", got)
+	}
+	tp.stop()
+
+	// Shutting down waits for the timers, so none are left by the time
+	// Start returns, and their tickers are off the clock.
+	if stacks := timerGoroutines(); len(stacks) > 0 {
+		t.Errorf("timers outlived the program:\n%s", stacks)
+	}
+	tp.clock.mtx.Lock()
+	defer tp.clock.mtx.Unlock()
+	for _, w := range tp.clock.waiting {
+		if w.period == time.Second {
+			t.Error("timer's ticker left on the clock")
+		}
+	}
+}
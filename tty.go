@@ -1,25 +1,117 @@
 package tea
 
 import (
-	"github.com/containerd/console"
-	"github.com/muesli/termenv"
+	"os"
+
+	"golang.org/x/crypto/ssh/terminal"
 )
 
-var tty console.Console
+// termState is the original state of a terminal device: its termios on Unix,
+// or its console mode on Windows.
+type termState struct {
+	fd    int
+	state *terminal.State
+}
+
+// termStore is where the state of terminal devices is read from and written
+// to. Programs use the system's, but tests can keep track of what's done to
+// terminals without there being any.
+type termStore interface {
+	isTerminal(fd int) bool
+	getState(fd int) (*terminal.State, error)
+	makeRaw(fd int) error
+	restore(fd int, state *terminal.State) error
+}
+
+// systemTermStore is the state of real terminal devices.
+type systemTermStore struct{}
+
+func (systemTermStore) isTerminal(fd int) bool {
+	return terminal.IsTerminal(fd)
+}
+
+func (systemTermStore) getState(fd int) (*terminal.State, error) {
+	return terminal.GetState(fd)
+}
+
+func (systemTermStore) makeRaw(fd int) error {
+	_, err := terminal.MakeRaw(fd)
+	return err
+}
+
+func (systemTermStore) restore(fd int, state *terminal.State) error {
+	return terminal.Restore(fd, state)
+}
+
+// initTerminal puts the terminal into raw mode. The original state of each
+// terminal device we touch is captured on the Program first, so that
+// restoreTerminal can put back exactly what this program found rather than
+// some assumed default.
+//
+// Input and output may be different devices, so each is tracked separately.
+// If neither is a terminal, such as when running against an in-memory
+// terminal, there's no terminal state to change.
+func (p *Program) initTerminal() error {
+	in, out := p.terminalFile(p.input), p.terminalFile(p.terminalOutput())
 
-func initTerminal() error {
-	tty = console.Current()
-	err := tty.SetRaw()
-	if err != nil {
-		return err
+	// Save the state of both devices before changing either of them, as they
+	// may well be one and the same.
+	for _, f := range []*os.File{in, out} {
+		if f == nil {
+			continue
+		}
+		state, err := p.terms.getState(int(f.Fd()))
+		if err != nil {
+			return err
+		}
+		p.termStates = append(p.termStates, termState{fd: int(f.Fd()), state: state})
 	}
 
-	enableAnsiColors()
-	termenv.HideCursor()
+	// Raw mode is for the benefit of input, but if input isn't a terminal
+	// we still don't want keypresses echoing onto the output.
+	raw := in
+	if raw == nil {
+		raw = out
+	}
+	if raw != nil {
+		if err := p.terms.makeRaw(int(raw.Fd())); err != nil {
+			return err
+		}
+	}
+
+	if out != nil {
+		enableAnsiColors(out)
+	}
 	return nil
 }
 
-func restoreTerminal() error {
-	termenv.ShowCursor()
-	return tty.Reset()
+// restoreTerminal returns each terminal device to the state it was in when
+// initTerminal was called.
+func (p *Program) restoreTerminal() error {
+	var err error
+	for i := len(p.termStates) - 1; i >= 0; i-- {
+		s := p.termStates[i]
+		if rerr := p.terms.restore(s.fd, s.state); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+	p.termStates = nil
+	return err
+}
+
+// terminalFile returns rw as a file if it's a terminal, going by the program's
+// termStore, and nil otherwise.
+func (p *Program) terminalFile(rw interface{}) *os.File {
+	if f, ok := rw.(*os.File); ok && p.terms.isTerminal(int(f.Fd())) {
+		return f
+	}
+	return nil
+}
+
+// terminalFile returns rw as a file if it's a terminal, and nil otherwise.
+func terminalFile(rw interface{}) *os.File {
+	if f, ok := rw.(*os.File); ok && terminal.IsTerminal(int(f.Fd())) {
+		return f
+	}
+	return nil
 }
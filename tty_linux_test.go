@@ -0,0 +1,270 @@
+// +build linux
+
+package tea
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// terminalRig runs a program on a pseudo-terminal, reading its input from the
+// terminal as well as drawing to it. The test plays the part of the terminal
+// from the master side.
+type terminalRig struct {
+	t      *testing.T
+	p      *Program
+	master *os.File
+	tty    *os.File
+	errc   chan error
+
+	out  []byte // everything written so far
+	seen int    // how much of out has been waited for
+}
+
+// startTerminalRig starts a program with the given update. The model's an
+// int, and the view's empty.
+func startTerminalRig(t *testing.T, update Update) *terminalRig {
+	t.Helper()
+	r := &terminalRig{t: t, errc: make(chan error, 1)}
+	r.master, r.tty = openPTY(t, 80, 24)
+	r.p = NewProgram(
+		func() (Model, Cmd) { return 0, nil },
+		update,
+		func(Model) string { return "" },
+		WithInput(r.tty),
+		WithOutput(r.tty),
+	)
+	go func() {
+		r.errc <- r.p.Start()
+	}()
+	return r
+}
+
+// waitFor reads the program's output until s is written, after whatever was
+// last waited for.
+func (r *terminalRig) waitFor(s string) {
+	r.t.Helper()
+	buf := make([]byte, 4096)
+	for {
+		if i := bytes.Index(r.out[r.seen:], []byte(s)); i >= 0 {
+			r.seen += i + len(s)
+			return
+		}
+		if err := r.master.SetReadDeadline(time.Now().Add(testTimeout)); err != nil {
+			r.t.Fatal(err)
+		}
+		n, err := r.master.Read(buf)
+		if err != nil {
+			r.t.Fatalf("waiting for %q: %v", s, err)
+		}
+		r.out = append(r.out, buf[:n]...)
+	}
+}
+
+// send sends s to the program as the terminal.
+func (r *terminalRig) send(s string) {
+	r.t.Helper()
+	if _, err := r.master.Write([]byte(s)); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+// finish waits for the program to exit and returns everything it wrote.
+func (r *terminalRig) finish() string {
+	r.t.Helper()
+	var (
+		rest []byte
+		done = make(chan struct{})
+	)
+	go func() {
+		defer close(done)
+		rest, _ = ioutil.ReadAll(r.master)
+	}()
+	select {
+	case err := <-r.errc:
+		if err != nil {
+			r.t.Error(err)
+		}
+	case <-time.After(testTimeout):
+		r.t.Error("program didn't quit")
+	}
+	r.tty.Close()
+	<-done
+	r.master.Close()
+	return string(r.out) + string(rest)
+}
+
+// quitOnQ is an Update that quits when q is pressed.
+func quitOnQ(msg Msg, m Model) (Model, Cmd) {
+	if k, ok := msg.(KeyMsg); ok && k.String() == "q" {
+		return m, Quit
+	}
+	return m, nil
+}
+
+// keyModeSeqs picks out the sequences that change the cursor key and keypad
+// modes from a program's output.
+var keyModeSeqs = regexp.MustCompile(regexp.QuoteMeta(appCursorKeysSeq) + "|" +
+	regexp.QuoteMeta(normalCursorKeysSeq) + "|" +
+	regexp.QuoteMeta(appKeypadSeq) + "|" +
+	regexp.QuoteMeta(normalKeypadSeq))
+
+func TestKeyModesRestored(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer string // the terminal's answer to the query, if any
+		want   []string
+	}{
+		{
+			name:   "normal",
+			answer: "\x1b[?1;2$y\x1b[?66;2$y",
+			want:   []string{appCursorKeysSeq, appKeypadSeq, normalKeypadSeq, normalCursorKeysSeq},
+		},
+		{
+			name:   "application",
+			answer: "\x1b[?1;1$y\x1b[?66;1$y",
+		},
+		{
+			name:   "application cursor keys",
+			answer: "\x1b[?1;1$y\x1b[?66;2$y",
+			want:   []string{appKeypadSeq, normalKeypadSeq},
+		},
+		{
+			name:   "application keypad",
+			answer: "\x1b[?1;2$y\x1b[?66;1$y",
+			want:   []string{appCursorKeysSeq, normalCursorKeysSeq},
+		},
+		{
+			// The terminal doesn't know the keypad mode.
+			name:   "unknown keypad",
+			answer: "\x1b[?1;2$y\x1b[?66;0$y",
+			want:   []string{appCursorKeysSeq, normalCursorKeysSeq},
+		},
+		{
+			// The terminal doesn't understand the query at all.
+			name: "no answer",
+		},
+		{
+			// The answer's read along with the key typed after it.
+			name:   "answer and key together",
+			answer: "\x1b[?1;2$y\x1b[?66;2$yq",
+			want:   []string{appCursorKeysSeq, appKeypadSeq, normalKeypadSeq, normalCursorKeysSeq},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := startTerminalRig(t, quitOnQ)
+			r.waitFor(requestKeyModesSeq)
+			r.send(tt.answer)
+			r.send("q")
+			if got := keyModeSeqs.FindAllString(r.finish(), -1); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyModesReleased(t *testing.T) {
+	var r *terminalRig
+	r = startTerminalRig(t, func(msg Msg, m Model) (Model, Cmd) {
+		if k, ok := msg.(KeyMsg); ok && k.String() == "r" {
+			return m, func() Msg {
+				if err := r.p.ReleaseTerminal(); err != nil {
+					t.Error(err)
+				}
+				if err := r.p.RestoreTerminal(); err != nil {
+					t.Error(err)
+				}
+				return nil
+			}
+		}
+		return quitOnQ(msg, m)
+	})
+	r.waitFor(requestKeyModesSeq)
+	r.send("\x1b[?1;2$y\x1b[?66;2$y")
+	r.waitFor(appCursorKeysSeq + appKeypadSeq)
+	r.send("r")
+	r.waitFor(appCursorKeysSeq + appKeypadSeq)
+	r.send("q")
+
+	// Releasing the terminal puts the modes back, and restoring it switches
+	// them again.
+	got := keyModeSeqs.FindAllString(r.finish(), -1)
+	want := []string{
+		appCursorKeysSeq, appKeypadSeq, normalKeypadSeq, normalCursorKeysSeq,
+		appCursorKeysSeq, appKeypadSeq, normalKeypadSeq, normalCursorKeysSeq,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// getTermios returns the termios of a terminal device.
+func getTermios(t *testing.T, f *os.File) unix.Termios {
+	t.Helper()
+	tios, err := unix.IoctlGetTermios(int(f.Fd()), unix.TCGETS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *tios
+}
+
+// setTermios sets the termios of a terminal device.
+func setTermios(t *testing.T, f *os.File, tios unix.Termios) {
+	t.Helper()
+	if err := unix.IoctlSetTermios(int(f.Fd()), unix.TCSETS, &tios); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTermiosRestored(t *testing.T) {
+	in, out := openTestPTY(t, 80, 24), openTestPTY(t, 80, 24)
+	defer in.close()
+	defer out.close()
+
+	// Configure the devices as a caller might have, each differently, so
+	// that putting back some default wouldn't do.
+	inTios, outTios := getTermios(t, in.tty), getTermios(t, out.tty)
+	inTios.Lflag &^= unix.ECHOCTL
+	inTios.Cc[unix.VMIN] = 3
+	outTios.Oflag &^= unix.ONLCR
+	outTios.Cc[unix.VTIME] = 7
+	setTermios(t, in.tty, inTios)
+	setTermios(t, out.tty, outTios)
+
+	var running [2]unix.Termios
+	p := NewProgram(
+		func() (Model, Cmd) { return 0, nil },
+		func(msg Msg, m Model) (Model, Cmd) {
+			if _, ok := msg.(WindowSizeMsg); ok {
+				running = [2]unix.Termios{getTermios(t, in.tty), getTermios(t, out.tty)}
+				return m, Quit
+			}
+			return m, nil
+		},
+		func(Model) string { return "" },
+		WithInput(in.tty),
+		WithOutput(out.tty),
+	)
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Input's in raw mode while the program runs.
+	if running[0].Lflag&(unix.ICANON|unix.ECHO) != 0 {
+		t.Errorf("input wasn't in raw mode: lflag %#x", running[0].Lflag)
+	}
+	if got := getTermios(t, in.tty); got != inTios {
+		t.Errorf("input termios\ngot  %+v\nwant %+v", got, inTios)
+	}
+	if got := getTermios(t, out.tty); got != outTios {
+		t.Errorf("output termios\ngot  %+v\nwant %+v", got, outTios)
+	}
+}
@@ -0,0 +1,257 @@
+package tea
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// fakeTermStore is a termStore for tests, where each terminal device's state
+// is just a name.
+type fakeTermStore struct {
+	modes map[int]string             // fd: the state it's in
+	saved map[*terminal.State]string // state handed out: the state it saved
+	fail  map[int]error              // fd: what restoring it fails with
+	log   []string
+}
+
+func newFakeTermStore() *fakeTermStore {
+	return &fakeTermStore{
+		modes: map[int]string{},
+		saved: map[*terminal.State]string{},
+		fail:  map[int]error{},
+	}
+}
+
+func (s *fakeTermStore) isTerminal(fd int) bool {
+	_, ok := s.modes[fd]
+	return ok
+}
+
+func (s *fakeTermStore) getState(fd int) (*terminal.State, error) {
+	s.log = append(s.log, fmt.Sprintf("save %d", fd))
+	state := &terminal.State{}
+	s.saved[state] = s.modes[fd]
+	return state, nil
+}
+
+func (s *fakeTermStore) makeRaw(fd int) error {
+	s.log = append(s.log, fmt.Sprintf("raw %d", fd))
+	s.modes[fd] = "raw"
+	return nil
+}
+
+func (s *fakeTermStore) restore(fd int, state *terminal.State) error {
+	s.log = append(s.log, fmt.Sprintf("restore %d", fd))
+	if err := s.fail[fd]; err != nil {
+		return err
+	}
+	s.modes[fd] = s.saved[state]
+	return nil
+}
+
+// fakeTerminals returns a fake termStore with the given files in it as
+// terminals, each in a state of its own.
+func fakeTerminals(files ...*os.File) *fakeTermStore {
+	s := newFakeTermStore()
+	for _, f := range files {
+		s.modes[int(f.Fd())] = fmt.Sprintf("cooked %d", f.Fd())
+	}
+	return s
+}
+
+// newTermProgram returns a program, which isn't started, with the given input
+// and output and a fake termStore.
+func newTermProgram(in, out *os.File, terms *fakeTermStore) *Program {
+	p := NewProgram(
+		func() (Model, Cmd) { return 0, nil },
+		func(msg Msg, m Model) (Model, Cmd) { return m, nil },
+		func(Model) string { return "" },
+		WithInput(in),
+		WithOutput(out),
+	)
+	p.terms = terms
+	return p
+}
+
+// openPipe opens a pipe, to stand in for a terminal device.
+func openPipe(t *testing.T) (r, w *os.File) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, w
+}
+
+// checkTerms fails the test unless what's been done to the terminals and the
+// state they're in is as expected.
+func checkTerms(t *testing.T, terms *fakeTermStore, log []string, modes map[int]string) {
+	t.Helper()
+	if !reflect.DeepEqual(terms.log, log) {
+		t.Errorf("got %q, want %q", terms.log, log)
+	}
+	if !reflect.DeepEqual(terms.modes, modes) {
+		t.Errorf("terminals left %q, want %q", terms.modes, modes)
+	}
+	terms.log = nil
+}
+
+func TestTermStatesSeparateDevices(t *testing.T) {
+	in, out := openPipe(t)
+	defer in.Close()
+	defer out.Close()
+	i, o := int(in.Fd()), int(out.Fd())
+
+	terms := fakeTerminals(in, out)
+	terms.modes[o] = "custom"
+	p := newTermProgram(in, out, terms)
+
+	// Both devices are saved before either's changed, and only input's put
+	// in raw mode.
+	if err := p.initTerminal(); err != nil {
+		t.Fatal(err)
+	}
+	checkTerms(t, terms,
+		[]string{fmt.Sprintf("save %d", i), fmt.Sprintf("save %d", o), fmt.Sprintf("raw %d", i)},
+		map[int]string{i: "raw", o: "custom"})
+
+	// Each is put back as it was found, in the opposite order.
+	if err := p.restoreTerminal(); err != nil {
+		t.Fatal(err)
+	}
+	checkTerms(t, terms,
+		[]string{fmt.Sprintf("restore %d", o), fmt.Sprintf("restore %d", i)},
+		map[int]string{i: fmt.Sprintf("cooked %d", i), o: "custom"})
+
+	// There's nothing more to restore.
+	if err := p.restoreTerminal(); err != nil {
+		t.Fatal(err)
+	}
+	checkTerms(t, terms, nil, map[int]string{i: fmt.Sprintf("cooked %d", i), o: "custom"})
+}
+
+func TestTermStatesOutputOnly(t *testing.T) {
+	in, out := openPipe(t)
+	defer in.Close()
+	defer out.Close()
+	o := int(out.Fd())
+
+	// With only output a terminal, that's what's put in raw mode, so that
+	// keys don't echo onto it.
+	terms := fakeTerminals(out)
+	p := newTermProgram(in, out, terms)
+	if err := p.initTerminal(); err != nil {
+		t.Fatal(err)
+	}
+	checkTerms(t, terms,
+		[]string{fmt.Sprintf("save %d", o), fmt.Sprintf("raw %d", o)},
+		map[int]string{o: "raw"})
+	if err := p.restoreTerminal(); err != nil {
+		t.Fatal(err)
+	}
+	checkTerms(t, terms,
+		[]string{fmt.Sprintf("restore %d", o)},
+		map[int]string{o: fmt.Sprintf("cooked %d", o)})
+}
+
+func TestTermStatesSameDevice(t *testing.T) {
+	f, w := openPipe(t)
+	defer f.Close()
+	defer w.Close()
+	fd := int(f.Fd())
+
+	terms := fakeTerminals(f)
+	p := newTermProgram(f, f, terms)
+	if err := p.initTerminal(); err != nil {
+		t.Fatal(err)
+	}
+	checkTerms(t, terms,
+		[]string{fmt.Sprintf("save %d", fd), fmt.Sprintf("save %d", fd), fmt.Sprintf("raw %d", fd)},
+		map[int]string{fd: "raw"})
+	if err := p.restoreTerminal(); err != nil {
+		t.Fatal(err)
+	}
+	checkTerms(t, terms,
+		[]string{fmt.Sprintf("restore %d", fd), fmt.Sprintf("restore %d", fd)},
+		map[int]string{fd: fmt.Sprintf("cooked %d", fd)})
+}
+
+func TestTermStatesNested(t *testing.T) {
+	f, w := openPipe(t)
+	defer f.Close()
+	defer w.Close()
+	fd := int(f.Fd())
+
+	terms := fakeTerminals(f)
+	outer := newTermProgram(f, w, terms)
+	if err := outer.initTerminal(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Something else sets the terminal up its own way, and runs a program
+	// of its own. That program puts back what it found, not what the
+	// outer one did.
+	terms.modes[fd] = "custom"
+	inner := newTermProgram(f, w, terms)
+	if err := inner.initTerminal(); err != nil {
+		t.Fatal(err)
+	}
+	if err := inner.restoreTerminal(); err != nil {
+		t.Fatal(err)
+	}
+	if got := terms.modes[fd]; got != "custom" {
+		t.Errorf("inner program left the terminal %q, want %q", got, "custom")
+	}
+
+	if err := outer.restoreTerminal(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := terms.modes[fd], fmt.Sprintf("cooked %d", fd); got != want {
+		t.Errorf("outer program left the terminal %q, want %q", got, want)
+	}
+}
+
+func TestTermStatesRestoreError(t *testing.T) {
+	in, out := openPipe(t)
+	defer in.Close()
+	defer out.Close()
+	i, o := int(in.Fd()), int(out.Fd())
+
+	// A device that can't be restored doesn't stop the other being
+	// restored, and the error's reported.
+	terms := fakeTerminals(in, out)
+	errGone := errors.New("device gone")
+	terms.fail[o] = errGone
+	p := newTermProgram(in, out, terms)
+	if err := p.initTerminal(); err != nil {
+		t.Fatal(err)
+	}
+	terms.log = nil
+	if err := p.restoreTerminal(); err != errGone {
+		t.Errorf("got %v, want %v", err, errGone)
+	}
+	checkTerms(t, terms,
+		[]string{fmt.Sprintf("restore %d", o), fmt.Sprintf("restore %d", i)},
+		map[int]string{i: fmt.Sprintf("cooked %d", i), o: fmt.Sprintf("cooked %d", o)})
+}
+
+func TestTermStatesNotTerminals(t *testing.T) {
+	in, out := openPipe(t)
+	defer in.Close()
+	defer out.Close()
+
+	terms := newFakeTermStore()
+	p := newTermProgram(in, out, terms)
+	if err := p.initTerminal(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.restoreTerminal(); err != nil {
+		t.Fatal(err)
+	}
+	checkTerms(t, terms, nil, map[int]string{})
+}
@@ -2,6 +2,8 @@
 
 package tea
 
+import "os"
+
 // enableAnsiColors is only needed for Windows, so for other systems this is
 // a no-op.
-func enableAnsiColors() {}
+func enableAnsiColors(*os.File) {}
@@ -10,8 +10,8 @@ import (
 
 // enableAnsiColors enables support for ANSI color sequences in Windows
 // default console. Note that this only works with Windows 10.
-func enableAnsiColors() {
-	stdout := windows.Handle(os.Stdout.Fd())
+func enableAnsiColors(f *os.File) {
+	stdout := windows.Handle(f.Fd())
 	var originalMode uint32
 
 	windows.GetConsoleMode(stdout, &originalMode)
@@ -0,0 +1,531 @@
+package tea
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+	te "github.com/muesli/termenv"
+)
+
+// VirtualTerminal is a minimal in-memory terminal emulator. It understands the
+// sequences Bubble Tea produces when rendering, which makes it possible to
+// test a program by looking at what actually ends up on screen, cell by cell,
+// rather than at raw output full of escape sequences. Attach it to a program
+// with WithOutput.
+//
+// A VirtualTerminal doesn't report its size to the program on its own, so
+// you'll usually want to send a WindowSizeMsg from Init:
+//
+//   vt := NewVirtualTerminal(80, 24)
+//   init := func() (Model, Cmd) {
+//       return model{}, func() Msg {
+//           return WindowSizeMsg{Width: 80, Height: 24}
+//       }
+//   }
+//   p := NewProgram(init, update, view, WithOutput(vt), WithInput(nil))
+//
+// It's safe to use a VirtualTerminal from multiple goroutines.
+type VirtualTerminal struct {
+	mtx sync.Mutex
+
+	width  int
+	height int
+
+	main      [][]Cell
+	alt       [][]Cell
+	altScreen bool
+
+	// cursor position, zero-based
+	x int
+	y int
+
+	// set when a rune has just been written to the last column. the next
+	// rune written will wrap onto the following line.
+	wrapNext bool
+
+	cursorHidden bool
+	style        CellStyle
+
+	// scrolling region, zero-based and inclusive
+	top    int
+	bottom int
+
+	// an incomplete sequence or rune left over from the last write
+	pending []byte
+}
+
+// Cell is a single cell on a VirtualTerminal's screen.
+type Cell struct {
+	// Rune is the character in the cell. It's zero for an empty cell and for
+	// the second cell of a double-width rune.
+	Rune  rune
+	Style CellStyle
+}
+
+// CellStyle describes how a Cell is styled. A nil color means the terminal's
+// default color.
+type CellStyle struct {
+	Foreground te.Color
+	Background te.Color
+
+	Bold      bool
+	Faint     bool
+	Italic    bool
+	Underline bool
+	Blink     bool
+	Reverse   bool
+	CrossOut  bool
+}
+
+// NewVirtualTerminal creates a VirtualTerminal with a screen of the given
+// size, in cells.
+func NewVirtualTerminal(width, height int) *VirtualTerminal {
+	t := &VirtualTerminal{
+		width:  width,
+		height: height,
+		bottom: height - 1,
+	}
+	t.main = newScreen(width, height)
+	t.alt = newScreen(width, height)
+	return t
+}
+
+// newScreen creates a blank screen of the given size.
+func newScreen(width, height int) [][]Cell {
+	s := make([][]Cell, height)
+	for i := range s {
+		s[i] = make([]Cell, width)
+	}
+	return s
+}
+
+// Size returns the size of the screen, in cells.
+func (t *VirtualTerminal) Size() (width, height int) {
+	return t.width, t.height
+}
+
+// Cell returns the cell at the given position, where (0, 0) is the top left
+// of the screen. Positions outside the screen return an empty cell.
+func (t *VirtualTerminal) Cell(x, y int) Cell {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if x < 0 || y < 0 || x >= t.width || y >= t.height {
+		return Cell{}
+	}
+	return t.screen()[y][x]
+}
+
+// Cursor returns the position of the cursor, where (0, 0) is the top left of
+// the screen, and whether it's visible.
+func (t *VirtualTerminal) Cursor() (x, y int, visible bool) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	return t.x, t.y, !t.cursorHidden
+}
+
+// AltScreen returns whether the alternate screen buffer is active.
+func (t *VirtualTerminal) AltScreen() bool {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	return t.altScreen
+}
+
+// String returns the text on screen, without any styling. Trailing spaces are
+// removed from each line.
+func (t *VirtualTerminal) String() string {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	lines := make([]string, t.height)
+	for y, row := range t.screen() {
+		var b strings.Builder
+		for x, c := range row {
+			switch {
+			case c.Rune != 0:
+				b.WriteRune(c.Rune)
+			case x > 0 && runewidth.RuneWidth(row[x-1].Rune) > 1:
+				// Second half of a wide rune
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		lines[y] = strings.TrimRight(b.String(), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Write processes output as a terminal would. It never returns an error.
+func (t *VirtualTerminal) Write(b []byte) (int, error) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	raw := append(t.pending, b...)
+	s := string(raw)
+	t.pending = nil
+
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == escape:
+			n := ansiSeqLen(raw[i:])
+			if i+n == len(s) && !seqComplete(s[i:]) {
+				t.pending = []byte(s[i:])
+				return len(b), nil
+			}
+			t.handleSeq(s[i : i+n])
+			i += n
+
+		case c < 0x20 || c == 0x7f:
+			t.handleControl(c)
+			i++
+
+		default:
+			if !utf8.FullRuneInString(s[i:]) {
+				t.pending = []byte(s[i:])
+				return len(b), nil
+			}
+			r, size := utf8.DecodeRuneInString(s[i:])
+			t.put(r)
+			i += size
+		}
+	}
+
+	return len(b), nil
+}
+
+// seqComplete reports whether an escape sequence which runs to the end of
+// the buffer is complete, or whether more of it is still to come.
+func seqComplete(seq string) bool {
+	if len(seq) < 2 {
+		return false
+	}
+	last := seq[len(seq)-1]
+
+	switch seq[1] {
+	case '[':
+		return len(seq) > 2 && last >= 0x40 && last <= 0x7e
+	case ']', 'P', 'X', '^', '_':
+		return last == bell || strings.HasSuffix(seq, "\x1b\\")
+	case 'O':
+		return len(seq) > 2
+	}
+	return last < 0x20 || last > 0x2f
+}
+
+// screen returns the active screen buffer.
+func (t *VirtualTerminal) screen() [][]Cell {
+	if t.altScreen {
+		return t.alt
+	}
+	return t.main
+}
+
+// put writes a rune at the cursor and advances it.
+func (t *VirtualTerminal) put(r rune) {
+	w := runewidth.RuneWidth(r)
+	if w == 0 || t.width == 0 || t.height == 0 {
+		return
+	}
+
+	if t.wrapNext || t.x+w > t.width {
+		t.x = 0
+		t.lineFeed()
+	}
+	t.wrapNext = false
+
+	row := t.screen()[t.y]
+	row[t.x] = Cell{Rune: r, Style: t.style}
+	for i := 1; i < w && t.x+i < t.width; i++ {
+		row[t.x+i] = Cell{Style: t.style}
+	}
+
+	if t.x+w >= t.width {
+		t.x = t.width - 1
+		t.wrapNext = true
+	} else {
+		t.x += w
+	}
+}
+
+// handleControl handles a C0 control character.
+func (t *VirtualTerminal) handleControl(c byte) {
+	t.wrapNext = false
+
+	switch c {
+	case '\r':
+		t.x = 0
+	case '\n', '\v', '\f':
+		t.lineFeed()
+	case '\b':
+		if t.x > 0 {
+			t.x--
+		}
+	case '\t':
+		t.x = min(t.width-1, (t.x/defaultTabWidth+1)*defaultTabWidth)
+	}
+}
+
+// lineFeed moves the cursor down a line, scrolling if it's at the bottom of
+// the scrolling region.
+func (t *VirtualTerminal) lineFeed() {
+	switch {
+	case t.y == t.bottom:
+		t.scrollUp(1)
+	case t.y < t.height-1:
+		t.y++
+	}
+}
+
+// scrollUp scrolls the scrolling region up by n lines, adding blank lines at
+// the bottom.
+func (t *VirtualTerminal) scrollUp(n int) {
+	t.deleteLines(t.top, n)
+}
+
+// scrollDown scrolls the scrolling region down by n lines, adding blank lines
+// at the top.
+func (t *VirtualTerminal) scrollDown(n int) {
+	t.insertLines(t.top, n)
+}
+
+// insertLines inserts n blank lines at row y, pushing the lines below it down
+// within the scrolling region.
+func (t *VirtualTerminal) insertLines(y, n int) {
+	if y < t.top || y > t.bottom {
+		return
+	}
+	s := t.screen()
+	for i := 0; i < n; i++ {
+		copy(s[y+1:t.bottom+1], s[y:t.bottom])
+		s[y] = make([]Cell, t.width)
+	}
+}
+
+// deleteLines removes n lines at row y, pulling the lines below it up within
+// the scrolling region.
+func (t *VirtualTerminal) deleteLines(y, n int) {
+	if y < t.top || y > t.bottom {
+		return
+	}
+	s := t.screen()
+	for i := 0; i < n; i++ {
+		copy(s[y:t.bottom], s[y+1:t.bottom+1])
+		s[t.bottom] = make([]Cell, t.width)
+	}
+}
+
+// erase blanks cells from (x0, y) up to but not including (x1, y).
+func (t *VirtualTerminal) erase(y, x0, x1 int) {
+	row := t.screen()[y]
+	for x := max(0, x0); x < min(x1, t.width); x++ {
+		row[x] = Cell{}
+	}
+}
+
+// handleSeq handles an escape sequence.
+func (t *VirtualTerminal) handleSeq(seq string) {
+	if len(seq) < 3 || seq[1] != '[' {
+		// Aside from CSI sequences we don't act on anything, but we're
+		// still careful not to print them.
+		return
+	}
+	t.wrapNext = false
+
+	final := seq[len(seq)-1]
+	params := seq[2 : len(seq)-1]
+	private := strings.HasPrefix(params, "?")
+	if private {
+		params = params[1:]
+	}
+
+	// Numeric parameters. Missing parameters are zero.
+	var args []int
+	if params != "" {
+		for _, p := range strings.Split(params, ";") {
+			n, _ := strconv.Atoi(p)
+			args = append(args, n)
+		}
+	}
+	arg := func(i, def int) int {
+		if i < len(args) && args[i] != 0 {
+			return args[i]
+		}
+		return def
+	}
+
+	switch final {
+	case 'A':
+		t.y = max(0, t.y-arg(0, 1))
+	case 'B':
+		t.y = min(t.height-1, t.y+arg(0, 1))
+	case 'C':
+		t.x = min(t.width-1, t.x+arg(0, 1))
+	case 'D':
+		t.x = max(0, t.x-arg(0, 1))
+	case 'E':
+		t.x, t.y = 0, min(t.height-1, t.y+arg(0, 1))
+	case 'F':
+		t.x, t.y = 0, max(0, t.y-arg(0, 1))
+	case 'G':
+		t.x = clamp(arg(0, 1)-1, 0, t.width-1)
+	case 'H', 'f':
+		t.y = clamp(arg(0, 1)-1, 0, t.height-1)
+		t.x = clamp(arg(1, 1)-1, 0, t.width-1)
+
+	case 'K':
+		switch arg(0, 0) {
+		case 0:
+			t.erase(t.y, t.x, t.width)
+		case 1:
+			t.erase(t.y, 0, t.x+1)
+		case 2:
+			t.erase(t.y, 0, t.width)
+		}
+	case 'J':
+		switch arg(0, 0) {
+		case 0:
+			t.erase(t.y, t.x, t.width)
+			for y := t.y + 1; y < t.height; y++ {
+				t.erase(y, 0, t.width)
+			}
+		case 1:
+			for y := 0; y < t.y; y++ {
+				t.erase(y, 0, t.width)
+			}
+			t.erase(t.y, 0, t.x+1)
+		case 2, 3:
+			for y := 0; y < t.height; y++ {
+				t.erase(y, 0, t.width)
+			}
+		}
+
+	case 'L':
+		t.insertLines(t.y, arg(0, 1))
+	case 'M':
+		t.deleteLines(t.y, arg(0, 1))
+	case 'S':
+		t.scrollUp(arg(0, 1))
+	case 'T':
+		t.scrollDown(arg(0, 1))
+
+	case 'r':
+		top, bottom := arg(0, 1)-1, arg(1, t.height)-1
+		if top < bottom && bottom < t.height {
+			t.top, t.bottom = top, bottom
+		} else {
+			t.top, t.bottom = 0, t.height-1
+		}
+		t.x, t.y = 0, 0
+
+	case 'm':
+		t.handleSGR(args)
+
+	case 'h', 'l':
+		if !private {
+			return
+		}
+		on := final == 'h'
+		for _, mode := range args {
+			switch mode {
+			case 25: // cursor visibility
+				t.cursorHidden = !on
+			case 1049: // alternate screen
+				if on && !t.altScreen {
+					t.alt = newScreen(t.width, t.height)
+				}
+				t.altScreen = on
+			}
+		}
+	}
+}
+
+// handleSGR applies Select Graphic Rendition parameters to the current style.
+func (t *VirtualTerminal) handleSGR(args []int) {
+	if len(args) == 0 {
+		args = []int{0}
+	}
+
+	for i := 0; i < len(args); i++ {
+		switch n := args[i]; {
+		case n == 0:
+			t.style = CellStyle{}
+		case n == 1:
+			t.style.Bold = true
+		case n == 2:
+			t.style.Faint = true
+		case n == 3:
+			t.style.Italic = true
+		case n == 4:
+			t.style.Underline = true
+		case n == 5:
+			t.style.Blink = true
+		case n == 7:
+			t.style.Reverse = true
+		case n == 9:
+			t.style.CrossOut = true
+		case n == 22:
+			t.style.Bold, t.style.Faint = false, false
+		case n == 23:
+			t.style.Italic = false
+		case n == 24:
+			t.style.Underline = false
+		case n == 25:
+			t.style.Blink = false
+		case n == 27:
+			t.style.Reverse = false
+		case n == 29:
+			t.style.CrossOut = false
+		case n >= 30 && n <= 37:
+			t.style.Foreground = te.ANSIColor(n - 30)
+		case n == 38:
+			t.style.Foreground, i = extendedColor(args, i)
+		case n == 39:
+			t.style.Foreground = nil
+		case n >= 40 && n <= 47:
+			t.style.Background = te.ANSIColor(n - 40)
+		case n == 48:
+			t.style.Background, i = extendedColor(args, i)
+		case n == 49:
+			t.style.Background = nil
+		case n >= 90 && n <= 97:
+			t.style.Foreground = te.ANSIColor(n - 90 + 8)
+		case n >= 100 && n <= 107:
+			t.style.Background = te.ANSIColor(n - 100 + 8)
+		}
+	}
+}
+
+// extendedColor parses a 256-color or true color SGR parameter starting at
+// args[i], which is 38 or 48. It returns the color and the index of the last
+// parameter consumed.
+func extendedColor(args []int, i int) (te.Color, int) {
+	if i+2 < len(args) && args[i+1] == 5 {
+		return te.ANSI256Color(args[i+2]), i + 2
+	}
+	if i+4 < len(args) && args[i+1] == 2 {
+		return te.RGBColor(fmt.Sprintf("#%02x%02x%02x", args[i+2], args[i+3], args[i+4])), i + 4
+	}
+	return nil, len(args)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func clamp(n, low, high int) int {
+	return max(low, min(n, high))
+}
@@ -0,0 +1,107 @@
+package tea
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// configDebounce is how long WatchConfig waits for a file to settle after the
+// last change before reading it. Editors often save in several steps (write a
+// temp file, rename it over the original, chmod, and so on) and we only want
+// to report the final result.
+const configDebounce = 100 * time.Millisecond
+
+// WatchConfig is a command that waits for the file at the given path to change
+// on disk and then sends a message containing its new contents. It's handy
+// for reloading configuration files while the program is running.
+//
+// To produce the command, pass a path and a function which returns a message
+// containing the new contents of the file, or an error if the file could not
+// be watched or read.
+//
+//   type ConfigMsg struct {
+//       Content []byte
+//       Err     error
+//   }
+//
+//   cmd := WatchConfig("config.yml", func(b []byte, err error) Msg {
+//       return ConfigMsg{b, err}
+//   })
+//
+// Like Tick, the command only fires once, and gives up if the program shuts
+// down first. To keep watching the file, return the command again from Update
+// when you receive the message.
+func WatchConfig(path string, fn func([]byte, error) Msg) Cmd {
+	return func() Msg {
+		return clockCmdMsg(func(ctx context.Context, c Clock) Msg {
+			return watchConfig(ctx, c, path, fn)
+		})
+	}
+}
+
+// watchConfig does the work for WatchConfig. It gives up, returning nil, if
+// ctx is done before the file changes.
+func watchConfig(ctx context.Context, c Clock, path string, fn func([]byte, error) Msg) Msg {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fn(nil, err)
+	}
+	defer w.Close()
+
+	// Watch the directory rather than the file itself. Many editors save
+	// atomically by renaming a temp file over the original, which would
+	// otherwise leave us watching a file that no longer exists.
+	if err := w.Add(filepath.Dir(path)); err != nil {
+		return fn(nil, err)
+	}
+	name := filepath.Clean(path)
+
+	var (
+		settle  Timer
+		settled <-chan time.Time
+	)
+	defer func() {
+		if settle != nil {
+			settle.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case e, ok := <-w.Events:
+			if !ok {
+				return fn(nil, errors.New("config watcher closed unexpectedly"))
+			}
+			if filepath.Clean(e.Name) != name || e.Op == fsnotify.Chmod {
+				continue
+			}
+			// Wait for things to quiet down before reading the file.
+			if settle != nil {
+				settle.Stop()
+			}
+			settle = c.NewTimer(configDebounce)
+			settled = settle.C()
+
+		case err, ok := <-w.Errors:
+			if !ok {
+				err = errors.New("config watcher closed unexpectedly")
+			}
+			return fn(nil, err)
+
+		case <-settled:
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				return fn(nil, err)
+			}
+			return fn(b, nil)
+		}
+	}
+}
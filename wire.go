@@ -0,0 +1,139 @@
+package tea
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// wireVersion is the version of the encoding used by MarshalMsg. It's bumped
+// whenever the encoding changes in a way older versions can't decode.
+const wireVersion = 1
+
+// wireEnvelope is how every message is encoded: the version, the kind of
+// message and, for messages that carry anything, the message itself.
+type wireEnvelope struct {
+	Version int             `json:"v"`
+	Type    string          `json:"type"`
+	Msg     json.RawMessage `json:"msg,omitempty"`
+}
+
+type wireKey struct {
+	Key string `json:"key"`
+}
+
+type wireMouse struct {
+	Event string `json:"event"`
+	X     int    `json:"x"`
+	Y     int    `json:"y"`
+}
+
+type wireWindowSize struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+type wirePaste struct {
+	Text      string `json:"text"`
+	Truncated bool   `json:"truncated"`
+}
+
+// wireDecoders decode the messages MarshalMsg can encode, by type.
+var wireDecoders = map[string]func(json.RawMessage) (Msg, error){
+	"key": func(data json.RawMessage) (Msg, error) {
+		var w wireKey
+		if err := json.Unmarshal(data, &w); err != nil {
+			return nil, err
+		}
+		return NewKeyMsg(w.Key)
+	},
+	"mouse": func(data json.RawMessage) (Msg, error) {
+		var w wireMouse
+		if err := json.Unmarshal(data, &w); err != nil {
+			return nil, err
+		}
+		m, err := NewMouseMsg(w.Event)
+		if err != nil {
+			return nil, err
+		}
+		m.X, m.Y = w.X, w.Y
+		return m, nil
+	},
+	"window-size": func(data json.RawMessage) (Msg, error) {
+		var w wireWindowSize
+		if err := json.Unmarshal(data, &w); err != nil {
+			return nil, err
+		}
+		return WindowSizeMsg{Width: w.Width, Height: w.Height}, nil
+	},
+	"paste": func(data json.RawMessage) (Msg, error) {
+		var w wirePaste
+		if err := json.Unmarshal(data, &w); err != nil {
+			return nil, err
+		}
+		return PasteMsg{Text: w.Text, Truncated: w.Truncated}, nil
+	},
+	"focus": func(json.RawMessage) (Msg, error) {
+		return FocusMsg{}, nil
+	},
+	"blur": func(json.RawMessage) (Msg, error) {
+		return BlurMsg{}, nil
+	},
+}
+
+// MarshalMsg encodes one of the messages that come from the terminal, namely
+// KeyMsg, MouseMsg, WindowSizeMsg, PasteMsg, FocusMsg and BlurMsg, as JSON.
+// It's for running a program's model somewhere other than the terminal it's
+// displayed on: encode messages where the terminal is, send them across, and
+// decode them with UnmarshalMsg to pass to Send.
+//
+// The encoding is versioned and stable: anything encoded by MarshalMsg can be
+// decoded by UnmarshalMsg from the same or any later version of this package.
+func MarshalMsg(msg Msg) ([]byte, error) {
+	var (
+		typ  string
+		body interface{}
+	)
+	switch m := msg.(type) {
+	case KeyMsg:
+		typ, body = "key", wireKey{Key: m.String()}
+	case MouseMsg:
+		typ, body = "mouse", wireMouse{Event: MouseEvent(m).String(), X: m.X, Y: m.Y}
+	case WindowSizeMsg:
+		typ, body = "window-size", wireWindowSize{Width: m.Width, Height: m.Height}
+	case PasteMsg:
+		typ, body = "paste", wirePaste{Text: m.Text, Truncated: m.Truncated}
+	case FocusMsg:
+		typ = "focus"
+	case BlurMsg:
+		typ = "blur"
+	default:
+		return nil, fmt.Errorf("can't marshal message of type %T", msg)
+	}
+
+	env := wireEnvelope{Version: wireVersion, Type: typ}
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		env.Msg = b
+	}
+	return json.Marshal(env)
+}
+
+// UnmarshalMsg decodes a message encoded by MarshalMsg back into a KeyMsg,
+// MouseMsg or whichever type it was.
+func UnmarshalMsg(data []byte) (Msg, error) {
+	var env wireEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, err
+	}
+	if env.Version < 1 || env.Version > wireVersion {
+		return nil, fmt.Errorf("can't unmarshal message of version %d", env.Version)
+	}
+	decode, ok := wireDecoders[env.Type]
+	if !ok {
+		return nil, fmt.Errorf("can't unmarshal message of unknown type %q", env.Type)
+	}
+	return decode(env.Msg)
+}
@@ -0,0 +1,90 @@
+package tea
+
+import "testing"
+
+// wireGolden pins the wire format: each message must encode to exactly this,
+// and this must decode back to the message, in this and every later version.
+var wireGolden = []struct {
+	msg  Msg
+	json string
+}{
+	{KeyMsg{Type: KeyRune, Rune: 'a'}, `{"v":1,"type":"key","msg":{"key":"a"}}`},
+	{KeyMsg{Type: KeyRune, Rune: '上'}, `{"v":1,"type":"key","msg":{"key":"上"}}`},
+	{KeyMsg{Type: KeyRune, Rune: '上', Alt: true}, `{"v":1,"type":"key","msg":{"key":"alt+上"}}`},
+	{KeyMsg{Type: KeyRune, Rune: '+', Alt: true}, `{"v":1,"type":"key","msg":{"key":"alt++"}}`},
+	{KeyMsg{Type: KeyEnter}, `{"v":1,"type":"key","msg":{"key":"enter"}}`},
+	{KeyMsg{Type: KeyEnter, Alt: true}, `{"v":1,"type":"key","msg":{"key":"alt+enter"}}`},
+	{KeyMsg{Type: KeyCtrlS}, `{"v":1,"type":"key","msg":{"key":"ctrl+s"}}`},
+	{KeyMsg{Type: KeyCtrlBackslash}, `{"v":1,"type":"key","msg":{"key":"ctrl+\\"}}`},
+	{KeyMsg{Type: KeyShiftTab}, `{"v":1,"type":"key","msg":{"key":"shift+tab"}}`},
+	{KeyMsg{Type: KeyPgDown, Alt: true}, `{"v":1,"type":"key","msg":{"key":"alt+pgdown"}}`},
+	{MouseMsg{Type: MouseLeft}, `{"v":1,"type":"mouse","msg":{"event":"left","x":0,"y":0}}`},
+	{MouseMsg{Type: MouseWheelUp, X: 12, Y: 3, Ctrl: true, Alt: true}, `{"v":1,"type":"mouse","msg":{"event":"ctrl+alt+wheel up","x":12,"y":3}}`},
+	{MouseMsg{Type: MouseRelease, X: 222, Y: 1}, `{"v":1,"type":"mouse","msg":{"event":"release","x":222,"y":1}}`},
+	{WindowSizeMsg{Width: 80, Height: 24}, `{"v":1,"type":"window-size","msg":{"width":80,"height":24}}`},
+	{WindowSizeMsg{}, `{"v":1,"type":"window-size","msg":{"width":0,"height":0}}`},
+	{PasteMsg{Text: "hello\nworld 上"}, `{"v":1,"type":"paste","msg":{"text":"hello\nworld 上","truncated":false}}`},
+	{PasteMsg{Text: "<b>", Truncated: true}, `{"v":1,"type":"paste","msg":{"text":"\u003cb\u003e","truncated":true}}`},
+	{FocusMsg{}, `{"v":1,"type":"focus"}`},
+	{BlurMsg{}, `{"v":1,"type":"blur"}`},
+}
+
+func TestMarshalMsg(t *testing.T) {
+	for _, tt := range wireGolden {
+		b, err := MarshalMsg(tt.msg)
+		if err != nil {
+			t.Errorf("%#v: %v", tt.msg, err)
+			continue
+		}
+		if string(b) != tt.json {
+			t.Errorf("%#v:\ngot  %s\nwant %s", tt.msg, b, tt.json)
+		}
+	}
+}
+
+func TestUnmarshalMsg(t *testing.T) {
+	for _, tt := range wireGolden {
+		msg, err := UnmarshalMsg([]byte(tt.json))
+		if err != nil {
+			t.Errorf("%s: %v", tt.json, err)
+			continue
+		}
+		if msg != tt.msg {
+			t.Errorf("%s: got %#v, want %#v", tt.json, msg, tt.msg)
+		}
+	}
+}
+
+func TestMsgRoundTrip(t *testing.T) {
+	for in, k := range parsedKeys(t) {
+		b, err := MarshalMsg(k)
+		if err != nil {
+			t.Errorf("%q: %v", in, err)
+			continue
+		}
+		if msg, err := UnmarshalMsg(b); err != nil || msg != k {
+			t.Errorf("%q: %s decodes to %#v, %v; want %#v", in, b, msg, err, k)
+		}
+	}
+}
+
+func TestUnmarshalMsgErrors(t *testing.T) {
+	for _, data := range []string{
+		``,
+		`{}`,
+		`{"v":0,"type":"focus"}`,
+		`{"v":2,"type":"focus"}`,
+		`{"v":1,"type":"scroll"}`,
+		`{"v":1,"type":"key","msg":{"key":"ctrl+nope"}}`,
+		`{"v":1,"type":"mouse","msg":{"event":"shift+left","x":0,"y":0}}`,
+		`{"v":1,"type":"window-size","msg":{"width":"wide"}}`,
+	} {
+		if msg, err := UnmarshalMsg([]byte(data)); err == nil {
+			t.Errorf("%s: got %#v, want an error", data, msg)
+		}
+	}
+
+	if _, err := MarshalMsg(quitMsg{}); err == nil {
+		t.Error("expected an error marshaling an unsupported message")
+	}
+}
@@ -0,0 +1,27 @@
+package tea
+
+// WrapInit wraps an Init function, passing the model and command it returns
+// through fn. This makes it possible to change a program's initial state or
+// startup command without touching its Init function, which is handy in
+// tests:
+//
+//   init = WrapInit(init, func(m Model, cmd Cmd) (Model, Cmd) {
+//       return m, nil // don't fetch anything at startup
+//   })
+func WrapInit(init Init, fn func(Model, Cmd) (Model, Cmd)) Init {
+	return func() (Model, Cmd) {
+		return fn(init())
+	}
+}
+
+// WrapView wraps a View function, passing the string it renders through
+// outer. Use it to post-process the whole of a program's output, such as to
+// add a border or line numbers. Wrapped views can themselves be wrapped, with
+// the outermost wrapper applied last:
+//
+//   view = WrapView(addBorder, WrapView(addLineNumbers, view))
+func WrapView(outer func(inner string) string, view View) View {
+	return func(m Model) string {
+		return outer(view(m))
+	}
+}